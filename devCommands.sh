@@ -19,7 +19,7 @@
 
 // ==== CHAINCODE INSTANTIATION (CLI) ==================
 
-// peer chaincode instantiate -n trustreputationledger -v 0 -c '{"Args":[]}' -C ch2
+// peer chaincode instantiate -n trustreputationledger -v 0 -c '{"Args":["init","Org1MSP"]}' -C ch2 (Org1MSP: ledger administrators)
 
 // ==== CHAINCODE EXECUTION SAMPLES (CLI) ==================
 
@@ -38,6 +38,7 @@
 // peer chaincode invoke -C ch2 -n scc -c '{"function": "CreateService", "Args":["idservice5","service1","description1asdfasdf"]}'
 // peer chaincode invoke -C ch2 -n scc -c '{"function": "CreateAgent", "Args":["idagent10","agent10","address10"]}'
 // peer chaincode invoke -C ch2 -n scc -c '{"function": "CreateServiceAgentRelation", "Args":["idservice1","idagent1","2","6"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "CreateServiceAgentRelation", "Args":["idservice2","idagent1","2.5 CHF","90m"]}'
 // peer chaincode invoke -C ch2 -n scc -c '{"function": "CreateServiceAndServiceAgentRelationWithStandardValue", "Args":["idservice1","service1","description1","idagent1","2","6"]}'
 // peer chaincode invoke -C ch2 -n scc -c '{"function": "CreateActivity", "Args":["idagent1","idagent4", "idagent1","idservice1","asdfCIAOsfasdfa","2018-07-23 16:51:01.2","2"]}'
 // peer chaincode invoke -C ch2 -n scc -c '{"function": "CreateReputation", "Args":["idagent1","idservice1", "DEMANDER","6"]}'
@@ -53,6 +54,85 @@
 // peer chaincode invoke -C ch2 -n scc -c '{"function": "GetServiceRelationAgent", "Args":["idservice1idagent1"]}'
 // peer chaincode invoke -C ch2 -n scc -c '{"function": "GetActivity", "Args":["idagent3idagent3idagent3asdfasfasdfa"]}'
 // peer chaincode invoke -C ch2 -n scc -c '{"function": "GetReputationNotFoundError", "Args":["idagent1idservice1EXECUTER"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetLedgerConfig", "Args":[]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "SetReputationModel", "Args":["EWMA"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "SetReputationModel", "Args":["BETA","idservice1"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "SetReputationModelParameters", "Args":["0.3","0","10"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "ComputeReputationWithModel", "Args":["idagent1","idservice1","EXECUTER","BETA"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "SetGlobalTrustParameters", "Args":["0.15","idagent1","idagent2"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "ComputeGlobalTrust", "Args":[]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetGlobalTrust", "Args":["idagent1"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetGlobalTrustHistory", "Args":["idagent1"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "SetDecayHalfLife", "Args":["720h"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetDecayedReputation", "Args":["idagent1","idservice1","EXECUTER"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetDecayedReputation", "Args":["idagent1","idservice1","EXECUTER","8760h"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "SetCredibilityWeighting", "Args":["true","0.5"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetDemanderReputationBreakdown", "Args":["idagent1","idservice1"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetCompositeServiceReputation", "Args":["idservice6","WEIGHTED_MEAN","idservice1:idagent1,idservice2:idagent2","idservice1:2"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetReputationsByAgentServiceRole", "Args":["idagent1","idservice1","EXECUTER"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "SetCollusionDetectionParameters", "Args":["true","2","5","1h","10"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "DetectCollusion", "Args":[]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetSuspicionReport", "Args":["suspicionReportRECIPROCAL_MAX_RATINGidagent1idagent2"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetSuspicionReportsByAgent", "Args":["idagent1"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "SetReputationModel", "Args":["MEDIAN","idservice1"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "SetOutlierFilter", "Args":["MAD","idservice1"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "SetRobustAggregationParameters", "Args":["0.1","3"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetReputationBreakdown", "Args":["idagent1","idservice1","EXECUTER"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "RecomputeReputations", "Args":["true"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "RecomputeReputations", "Args":[]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "SnapshotReputations", "Args":["2026-03"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetEpoch", "Args":["2026-03"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetReputationsAtEpoch", "Args":["2026-03","idagent1"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "DiffReputationEpochs", "Args":["2026-02","2026-03"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetTopExecutersForService", "Args":["idservice1","3"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetTopExecutersForService", "Args":["idservice1","3","DEMANDER"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "SelectExecuter", "Args":["idservice1","reputation:0.6,cost:0.3,time:0.1"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "SelectExecuter", "Args":["idservice1","reputation:1,cost:1","maxCost:10,minReputation:5"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "PlanCompositeExecution", "Args":["idservice6","COST","6","100"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "PlanCompositeExecution", "Args":["idservice6","TIME","idservice1:7,idservice2:5","50"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetExecutionPlansByService", "Args":["idservice6"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetAgentGlobalReputation", "Args":["idagent1"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetAgentGlobalReputation", "Args":["idagent1","EXECUTER"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "ModifyServiceCategory", "Args":["idservice1","storage"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "SetColdStartWeight", "Args":["0.5"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "InferInitialReputation", "Args":["idagent1","idservice2"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "SetDisputedEvaluationWeight", "Args":["0"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "OpenDispute", "Args":["idagent99idagent98idagent99tx1","idagent99","late delivery","9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "RespondDispute", "Args":["idagent99idagent98idagent99tx1","idagent98","delivered on time"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "ResolveDispute", "Args":["idagent99idagent98idagent99tx1","RESOLVED_OVERTURNED","evidence accepted"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "QueryDispute", "Args":["disputeidagent99idagent98idagent99tx1"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetDisputesByAgent", "Args":["idagent99"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "SetCommitRevealWindows", "Args":["24h","24h"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "CommitEvaluation", "Args":["idagent98","idagent98","idagent99","idservice99","tx1","e72e332204e5a9a4814a2066d827fe9cb29b7bf120318e4deaf2fa1bf555743e"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "RevealEvaluation", "Args":["idagent98","idagent98","idagent99","tx1","2018-07-23 16:51:01.2","8","salt"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "CloseEvaluationCommitments", "Args":["tx1"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "QueryEvaluationCommitment", "Args":["commitmentidagent98idagent98idagent99tx1"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetEvaluationCommitmentsByServiceTx", "Args":["tx1"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetMissingReviewsByAgent", "Args":["idagent99"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "RequestServiceExecution", "Args":["idagent98","idagent99","idservice99"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "AcceptServiceExecution", "Args":["<ExecutionId>","idagent99"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "DeliverServiceExecution", "Args":["<ExecutionId>","idagent99"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "CompleteServiceExecution", "Args":["<ExecutionId>","idagent98"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "CancelServiceExecution", "Args":["<ExecutionId>","idagent98"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "QueryServiceExecution", "Args":["<ExecutionId>"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetServiceExecutionsByAgent", "Args":["idagent98"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "CreateServiceRequest", "Args":["idagent98","idservice99","10","2030-01-01T00:00:00Z","0"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetOpenServiceRequestsByService", "Args":["idservice99"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "SubmitBid", "Args":["<RequestId>","idagent99","5","7"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetBidsByRequest", "Args":["<RequestId>"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "QueryBid", "Args":["<RequestId>idagent99"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "AcceptBid", "Args":["<RequestId>idagent99","idagent98"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "QueryServiceRequest", "Args":["<RequestId>"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "CancelServiceRequest", "Args":["<RequestId>","idagent98"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "Mint", "Args":["idagent98","100"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "Transfer", "Args":["idagent98","idagent1","10"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetBalance", "Args":["idagent98"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "QueryEscrow", "Args":["<ExecutionId>"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetLedgerEntriesByAgent", "Args":["idagent98"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "DeliverServiceExecution", "Args":["<ExecutionId>","idagent99","6"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetSlaReport", "Args":["idagent99","idservice99"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "GetSlaRecordsByRelation", "Args":["idservice99idagent99"]}'
+// peer chaincode invoke -C ch2 -n scc -c '{"function": "SetSlaPenaltyWeight", "Args":["0.5"]}'
 
 
 // ==== GET HISTORY ==================
@@ -69,6 +69,76 @@ const(
 	GetReputationHistory = "GetReputationHistory"
 	AllStateDB = "AllStateDB"
 	GetValue = "GetValue"
+	GetLedgerConfig = "GetLedgerConfig"
+	SetReputationModel = "SetReputationModel"
+	SetReputationModelParameters = "SetReputationModelParameters"
+	ComputeReputationWithModel = "ComputeReputationWithModel"
+	ComputeGlobalTrust = "ComputeGlobalTrust"
+	GetGlobalTrust = "GetGlobalTrust"
+	GetGlobalTrustHistory = "GetGlobalTrustHistory"
+	SetGlobalTrustParameters = "SetGlobalTrustParameters"
+	SetDecayHalfLife = "SetDecayHalfLife"
+	GetDecayedReputation = "GetDecayedReputation"
+	SetCredibilityWeighting = "SetCredibilityWeighting"
+	GetDemanderReputationBreakdown = "GetDemanderReputationBreakdown"
+	GetCompositeServiceReputation = "GetCompositeServiceReputation"
+	SetCollusionDetectionParameters = "SetCollusionDetectionParameters"
+	DetectCollusion = "DetectCollusion"
+	GetSuspicionReport = "GetSuspicionReport"
+	GetSuspicionReportsByAgent = "GetSuspicionReportsByAgent"
+	SetOutlierFilter = "SetOutlierFilter"
+	SetRobustAggregationParameters = "SetRobustAggregationParameters"
+	GetReputationBreakdown = "GetReputationBreakdown"
+	RecomputeReputations = "RecomputeReputations"
+	SnapshotReputations = "SnapshotReputations"
+	GetEpoch = "GetEpoch"
+	GetReputationsAtEpoch = "GetReputationsAtEpoch"
+	DiffReputationEpochs = "DiffReputationEpochs"
+	GetTopExecutersForService = "GetTopExecutersForService"
+	SelectExecuter = "SelectExecuter"
+	PlanCompositeExecution = "PlanCompositeExecution"
+	GetExecutionPlan = "GetExecutionPlan"
+	GetExecutionPlansByService = "GetExecutionPlansByService"
+	GetAgentGlobalReputation = "GetAgentGlobalReputation"
+	ModifyServiceCategory = "ModifyServiceCategory"
+	SetColdStartWeight = "SetColdStartWeight"
+	InferInitialReputation = "InferInitialReputation"
+	SetDisputedEvaluationWeight = "SetDisputedEvaluationWeight"
+	OpenDispute = "OpenDispute"
+	RespondDispute = "RespondDispute"
+	ResolveDispute = "ResolveDispute"
+	QueryDispute = "QueryDispute"
+	GetDisputesByAgent = "GetDisputesByAgent"
+	SetCommitRevealWindows = "SetCommitRevealWindows"
+	CommitEvaluation = "CommitEvaluation"
+	RevealEvaluation = "RevealEvaluation"
+	CloseEvaluationCommitments = "CloseEvaluationCommitments"
+	QueryEvaluationCommitment = "QueryEvaluationCommitment"
+	GetEvaluationCommitmentsByServiceTx = "GetEvaluationCommitmentsByServiceTx"
+	GetMissingReviewsByAgent = "GetMissingReviewsByAgent"
+	RequestServiceExecution = "RequestServiceExecution"
+	AcceptServiceExecution = "AcceptServiceExecution"
+	DeliverServiceExecution = "DeliverServiceExecution"
+	CompleteServiceExecution = "CompleteServiceExecution"
+	CancelServiceExecution = "CancelServiceExecution"
+	QueryServiceExecution = "QueryServiceExecution"
+	GetServiceExecutionsByAgent = "GetServiceExecutionsByAgent"
+	CreateServiceRequest = "CreateServiceRequest"
+	CancelServiceRequest = "CancelServiceRequest"
+	SubmitBid = "SubmitBid"
+	AcceptBid = "AcceptBid"
+	QueryServiceRequest = "QueryServiceRequest"
+	QueryBid = "QueryBid"
+	GetOpenServiceRequestsByService = "GetOpenServiceRequestsByService"
+	GetBidsByRequest = "GetBidsByRequest"
+	Mint = "Mint"
+	Transfer = "Transfer"
+	GetBalance = "GetBalance"
+	GetLedgerEntriesByAgent = "GetLedgerEntriesByAgent"
+	QueryEscrow = "QueryEscrow"
+	GetSlaReport = "GetSlaReport"
+	GetSlaRecordsByRelation = "GetSlaRecordsByRelation"
+	SetSlaPenaltyWeight = "SetSlaPenaltyWeight"
 	HelloWorld = "HelloWorld"
 
 )
@@ -97,8 +167,16 @@ func main() {
 // ============================================================================================================================
 // The Init method is called when the Smart Contract "trustreputationledger" is instantiated by the blockchain network
 // Best practice is to have any Ledger initialization in separate function -- see InitLedger()
+// Arguments: "init", "AdminMspId1", ... , "AdminMspIdN" - the organizations allowed to do the administrative overrides
+// (at least one at the instantiation, optional at the upgrade: the saved administrators are kept)
 // ============================================================================================================================
 func (t *SimpleChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	// LEDGER CONFIGURATION
+	_, adminMspIds := stub.GetFunctionAndParameters()
+	configError := a.InitLedgerConfig(adminMspIds, stub)
+	if configError != nil {
+		return shim.Error(configError.Error())
+	}
 	// TEST BEHAVIOUR
 	if t.testMode {
 		a.InitLedger(stub)
@@ -248,6 +326,146 @@ func (t *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 		return gen.ReadAllStateDB(stub)
 	case GetValue:
 		return gen.GetValue(stub, args)
+	case GetLedgerConfig:
+		return in.QueryLedgerConfig(stub, args)
+	case SetReputationModel:
+		return in.SetReputationModel(stub, args)
+	case SetReputationModelParameters:
+		return in.SetReputationModelParameters(stub, args)
+	case ComputeReputationWithModel:
+		return in.ComputeReputationWithModel(stub, args)
+	case ComputeGlobalTrust:
+		return in.ComputeGlobalTrust(stub, args)
+	case GetGlobalTrust:
+		return in.QueryGlobalTrust(stub, args)
+	case GetGlobalTrustHistory:
+		return in.GetGlobalTrustHistory(stub, args)
+	case SetGlobalTrustParameters:
+		return in.SetGlobalTrustParameters(stub, args)
+	case SetDecayHalfLife:
+		return in.SetDecayHalfLife(stub, args)
+	case GetDecayedReputation:
+		return in.GetDecayedReputation(stub, args)
+	case SetCredibilityWeighting:
+		return in.SetCredibilityWeighting(stub, args)
+	case GetDemanderReputationBreakdown:
+		return in.GetDemanderReputationBreakdown(stub, args)
+	case GetCompositeServiceReputation:
+		return in.GetCompositeServiceReputation(stub, args)
+	case SetCollusionDetectionParameters:
+		return in.SetCollusionDetectionParameters(stub, args)
+	case DetectCollusion:
+		return in.DetectCollusion(stub, args)
+	case GetSuspicionReport:
+		return in.QuerySuspicionReport(stub, args)
+	case GetSuspicionReportsByAgent:
+		return in.GetSuspicionReportsByAgent(stub, args)
+	case SetOutlierFilter:
+		return in.SetOutlierFilter(stub, args)
+	case SetRobustAggregationParameters:
+		return in.SetRobustAggregationParameters(stub, args)
+	case GetReputationBreakdown:
+		return in.GetReputationBreakdown(stub, args)
+	case RecomputeReputations:
+		return in.RecomputeReputations(stub, args)
+	case SnapshotReputations:
+		return in.SnapshotReputations(stub, args)
+	case GetEpoch:
+		return in.QueryEpoch(stub, args)
+	case GetReputationsAtEpoch:
+		return in.GetReputationsAtEpoch(stub, args)
+	case DiffReputationEpochs:
+		return in.DiffReputationEpochs(stub, args)
+	case GetTopExecutersForService:
+		return in.GetTopExecutersForService(stub, args)
+	case SelectExecuter:
+		return in.SelectExecuter(stub, args)
+	case PlanCompositeExecution:
+		return in.PlanCompositeExecution(stub, args)
+	case GetExecutionPlan:
+		return in.QueryExecutionPlan(stub, args)
+	case GetExecutionPlansByService:
+		return in.GetExecutionPlansByService(stub, args)
+	case GetAgentGlobalReputation:
+		return in.GetAgentGlobalReputation(stub, args)
+	case ModifyServiceCategory:
+		return in.ModifyServiceCategory(stub, args)
+	case SetColdStartWeight:
+		return in.SetColdStartWeight(stub, args)
+	case InferInitialReputation:
+		return in.InferInitialReputation(stub, args)
+	case SetDisputedEvaluationWeight:
+		return in.SetDisputedEvaluationWeight(stub, args)
+	case OpenDispute:
+		return in.OpenDispute(stub, args)
+	case RespondDispute:
+		return in.RespondDispute(stub, args)
+	case ResolveDispute:
+		return in.ResolveDispute(stub, args)
+	case QueryDispute:
+		return in.QueryDispute(stub, args)
+	case GetDisputesByAgent:
+		return in.GetDisputesByAgent(stub, args)
+	case SetCommitRevealWindows:
+		return in.SetCommitRevealWindows(stub, args)
+	case CommitEvaluation:
+		return in.CommitEvaluation(stub, args)
+	case RevealEvaluation:
+		return in.RevealEvaluation(stub, args)
+	case CloseEvaluationCommitments:
+		return in.CloseEvaluationCommitments(stub, args)
+	case QueryEvaluationCommitment:
+		return in.QueryEvaluationCommitment(stub, args)
+	case GetEvaluationCommitmentsByServiceTx:
+		return in.GetEvaluationCommitmentsByServiceTx(stub, args)
+	case GetMissingReviewsByAgent:
+		return in.GetMissingReviewsByAgent(stub, args)
+	case RequestServiceExecution:
+		return in.RequestServiceExecution(stub, args)
+	case AcceptServiceExecution:
+		return in.AcceptServiceExecution(stub, args)
+	case DeliverServiceExecution:
+		return in.DeliverServiceExecution(stub, args)
+	case CompleteServiceExecution:
+		return in.CompleteServiceExecution(stub, args)
+	case CancelServiceExecution:
+		return in.CancelServiceExecution(stub, args)
+	case QueryServiceExecution:
+		return in.QueryServiceExecution(stub, args)
+	case GetServiceExecutionsByAgent:
+		return in.GetServiceExecutionsByAgent(stub, args)
+	case CreateServiceRequest:
+		return in.CreateServiceRequest(stub, args)
+	case CancelServiceRequest:
+		return in.CancelServiceRequest(stub, args)
+	case SubmitBid:
+		return in.SubmitBid(stub, args)
+	case AcceptBid:
+		return in.AcceptBid(stub, args)
+	case QueryServiceRequest:
+		return in.QueryServiceRequest(stub, args)
+	case QueryBid:
+		return in.QueryBid(stub, args)
+	case GetOpenServiceRequestsByService:
+		return in.GetOpenServiceRequestsByService(stub, args)
+	case GetBidsByRequest:
+		return in.GetBidsByRequest(stub, args)
+	case Mint:
+		return in.Mint(stub, args)
+	case Transfer:
+		return in.Transfer(stub, args)
+	case GetBalance:
+		return in.GetBalance(stub, args)
+	case GetLedgerEntriesByAgent:
+		return in.GetLedgerEntriesByAgent(stub, args)
+	case QueryEscrow:
+		return in.QueryEscrow(stub, args)
+	case GetSlaReport:
+		return in.GetSlaReport(stub, args)
+	case GetSlaRecordsByRelation:
+		return in.GetSlaRecordsByRelation(stub, args)
+	case SetSlaPenaltyWeight:
+		return in.SetSlaPenaltyWeight(stub, args)
 	case HelloWorld:
 		log.Info("Hello, lorem ipsum")
 		var buffer bytes.Buffer
@@ -18,16 +18,29 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	lib "github.com/pavva91/arglib"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/msp"
+	pb "github.com/hyperledger/fabric/protos/peer"
 
 	a "github.com/pavva91/assets"
 )
 
 var testLog = shim.NewLogger("trustreputationledger_test")
 
+// Organizations of the clients submitting the transactions of the tests: AdminMspId is the ledger administrator
+const (
+	AdminMspId = "Org1MSP"
+	UserMspId  = "Org2MSP"
+)
+
 const (
 	ExistingServiceId          = "idservice1"
 	ExistingServiceName        = "service1"
@@ -70,7 +83,7 @@ const (
 )
 
 func checkInit(t *testing.T, stub *shim.MockStub, args [][]byte) {
-	res := stub.MockInit("1", args)
+	res := mockInit(stub, "1", args)
 	if res.Status != shim.OK {
 		testLog.Info("Init failed", string(res.Message))
 		t.FailNow()
@@ -105,7 +118,7 @@ func checkState(t *testing.T, stub *shim.MockStub, name string, value string) {
 }
 
 func checkBadQuery(t *testing.T, stub *shim.MockStub, function string, name string) {
-	res := stub.MockInvoke("1", [][]byte{[]byte(function), []byte(name)})
+	res := mockInvoke(stub, "1", [][]byte{[]byte(function), []byte(name)})
 	if res.Status == shim.OK {
 		testLog.Info("Query", name, "unexpectedly succeeded")
 		t.FailNow()
@@ -116,7 +129,7 @@ func checkBadQuery(t *testing.T, stub *shim.MockStub, function string, name stri
 }
 
 func checkQuery(t *testing.T, stub *shim.MockStub, function string, name string, value string) {
-	res := stub.MockInvoke("1", [][]byte{[]byte(function), []byte(name)})
+	res := mockInvoke(stub, "1", [][]byte{[]byte(function), []byte(name)})
 	if res.Status != shim.OK {
 		testLog.Info("Query", name, "failed", string(res.Message))
 		t.FailNow()
@@ -135,7 +148,7 @@ func checkQuery(t *testing.T, stub *shim.MockStub, function string, name string,
 }
 
 func checkQueryArgs(t *testing.T, stub *shim.MockStub, args [][]byte, value string) {
-	res := stub.MockInvoke("1", args)
+	res := mockInvoke(stub, "1", args)
 	if res.Status != shim.OK {
 		testLog.Info("Query", string(args[1]), "failed", string(res.Message))
 		t.FailNow()
@@ -154,9 +167,51 @@ func checkQueryArgs(t *testing.T, stub *shim.MockStub, args [][]byte, value stri
 	}
 }
 
+func checkReputationValue(t *testing.T, stub *shim.MockStub, reputationId string, value string) {
+	res := mockInvoke(stub, "1", [][]byte{[]byte(GetReputation), []byte(reputationId)})
+	if res.Status != shim.OK {
+		testLog.Info("Query", reputationId, "failed", string(res.Message))
+		t.FailNow()
+	}
+	var reputation a.Reputation
+	json.Unmarshal(res.Payload, &reputation)
+	if reputation.Value != toScore(value) {
+		testLog.Info("Reputation value", reputationId, "was", reputation.Value, "and not", value, "as expected")
+		t.FailNow()
+	}else {
+		testLog.Info("Reputation value", reputationId, "is", reputation.Value, "as expected")
+	}
+}
+
+// completeServiceExecution - save a COMPLETED service execution (if not already saved), so that the activities of the
+// tests can evaluate it (the lifecycle of the executions is tested in TestServiceExecutionLifecycle)
+func completeServiceExecution(t *testing.T, stub *shim.MockStub, executionId string, demanderAgentId string, executerAgentId string, serviceId string) {
+	if stub.State[executionId] != nil {
+		return
+	}
+	execution := a.ServiceExecution{ExecutionId: executionId, RelationId: serviceId + executerAgentId, ExecutedServiceId: serviceId, DemanderAgentId: demanderAgentId, ExecuterAgentId: executerAgentId, Status: a.ExecutionCompleted}
+	stub.State[executionId], _ = json.Marshal(execution)
+}
+
+// toScore, toCost, toDuration - the typed values of the string arguments of the invokes
+func toScore(value string) a.Score {
+	score, _ := a.ParseScore(value)
+	return score
+}
+
+func toCost(value string) a.Cost {
+	cost, _ := a.ParseCost(value)
+	return cost
+}
+
+func toDuration(value string) a.Duration {
+	duration, _ := a.ParseDuration(value)
+	return duration
+}
+
 func checkBadInvoke(t *testing.T, stub *shim.MockStub, functionAndArgs []string) {
 	functionAndArgsAsBytes := lib.ParseStringSliceToByteSlice(functionAndArgs)
-	res := stub.MockInvoke("1", functionAndArgsAsBytes)
+	res := mockInvoke(stub, "1", functionAndArgsAsBytes)
 	if res.Status == shim.OK {
 		testLog.Info("Invoke", functionAndArgs, "unexpectedly succeeded")
 		t.FailNow()
@@ -176,7 +231,7 @@ func checkBadInvoke(t *testing.T, stub *shim.MockStub, functionAndArgs []string)
 // }
 func checkInvoke(t *testing.T, stub *shim.MockStub, functionAndArgs []string) {
 	functionAndArgsAsBytes := lib.ParseStringSliceToByteSlice(functionAndArgs)
-	res := stub.MockInvoke("1", functionAndArgsAsBytes)
+	res := mockInvoke(stub, "1", functionAndArgsAsBytes)
 	if res.Status != shim.OK {
 		testLog.Info("Invoke", functionAndArgs, "failed", string(res.Message))
 		t.FailNow()
@@ -185,8 +240,157 @@ func checkInvoke(t *testing.T, stub *shim.MockStub, functionAndArgs []string) {
 	}
 }
 
+func checkBadInvokeAs(t *testing.T, stub *shim.MockStub, creatorMspId string, functionAndArgs []string) {
+	functionAndArgsAsBytes := lib.ParseStringSliceToByteSlice(functionAndArgs)
+	res := mockInvokeAs(stub, creatorMspId, "1", functionAndArgsAsBytes)
+	if res.Status == shim.OK {
+		testLog.Info("Invoke", functionAndArgs, "by", creatorMspId, "unexpectedly succeeded")
+		t.FailNow()
+	}else {
+		testLog.Info("Invoke", functionAndArgs, "by", creatorMspId, "failed as espected, with message: "+ res.Message)
+	}
+}
+
 func getInitArguments() [][]byte {
-	return [][]byte{}
+	return [][]byte{[]byte("init"), []byte(AdminMspId)}
+}
+
+// =====================================================================================================================
+// transactionStub - the MockStub seen by the chaincode in a transaction submitted by a client of the organization
+// creatorMspId (the creator of the MockStub is always nil and its arguments are set by MockInit and MockInvoke only)
+// =====================================================================================================================
+type transactionStub struct {
+	*shim.MockStub
+	args           [][]byte
+	creatorMspId   string
+	committedReads bool
+	writeKeys      []string
+	writes         map[string][]byte
+}
+
+func (stub *transactionStub) GetArgs() [][]byte {
+	return stub.args
+}
+
+func (stub *transactionStub) GetStringArgs() []string {
+	var strargs []string
+	for _, barg := range stub.args {
+		strargs = append(strargs, string(barg))
+	}
+	return strargs
+}
+
+func (stub *transactionStub) GetFunctionAndParameters() (function string, params []string) {
+	allargs := stub.GetStringArgs()
+	function = ""
+	params = []string{}
+	if len(allargs) >= 1 {
+		function = allargs[0]
+		params = allargs[1:]
+	}
+	return
+}
+
+func (stub *transactionStub) GetCreator() ([]byte, error) {
+	creator := msp.SerializedIdentity{Mspid: stub.creatorMspId, IdBytes: []byte("User1@" + stub.creatorMspId)}
+	return proto.Marshal(&creator)
+}
+
+// PutState, DelState - with committedReads the writes are kept aside until the end of the transaction, as on a peer
+// (the reads return the state committed before the transaction, while the MockStub returns the writes of the same one)
+func (stub *transactionStub) PutState(key string, value []byte) error {
+	if !stub.committedReads {
+		return stub.MockStub.PutState(key, value)
+	}
+	if stub.writes == nil {
+		stub.writes = make(map[string][]byte)
+	}
+	if _, ok := stub.writes[key]; !ok {
+		stub.writeKeys = append(stub.writeKeys, key)
+	}
+	stub.writes[key] = value
+	return nil
+}
+
+func (stub *transactionStub) DelState(key string) error {
+	if !stub.committedReads {
+		return stub.MockStub.DelState(key)
+	}
+	return stub.PutState(key, nil)
+}
+
+// commit - apply the writes kept aside to the state of the MockStub
+func (stub *transactionStub) commit() error {
+	for _, key := range stub.writeKeys {
+		var err error
+		if stub.writes[key] == nil {
+			err = stub.MockStub.DelState(key)
+		} else {
+			err = stub.MockStub.PutState(key, stub.writes[key])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// failingStub - the MockStub whose PutState fails on failingKey (a write refused by the peer)
+type failingStub struct {
+	*shim.MockStub
+	failingKey string
+}
+
+func (stub *failingStub) PutState(key string, value []byte) error {
+	if key == stub.failingKey {
+		return errors.New("PutState of " + key + " failed")
+	}
+	return stub.MockStub.PutState(key, value)
+}
+
+// getCreatorIdentity - the identity of the client of the organization creatorMspId (the owner of the agents it creates)
+func getCreatorIdentity(creatorMspId string) string {
+	creatorIdentity, _ := a.GetCreatorIdentity(&transactionStub{creatorMspId: creatorMspId})
+	return creatorIdentity
+}
+
+// mockInit - MockInit of the chaincode in test mode, submitted by a client of the administrators organization
+func mockInit(stub *shim.MockStub, txId string, args [][]byte) pb.Response {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	stub.MockTransactionStart(txId)
+	res := simpleChaincode.Init(&transactionStub{MockStub: stub, args: args, creatorMspId: AdminMspId})
+	stub.MockTransactionEnd(txId)
+	return res
+}
+
+// mockInvoke - MockInvoke submitted by a client of the administrators organization
+func mockInvoke(stub *shim.MockStub, txId string, args [][]byte) pb.Response {
+	return mockInvokeAs(stub, AdminMspId, txId, args)
+}
+
+// mockInvokeCommittedReads - MockInvoke executed as on a peer: the reads return the state committed before the
+// transaction, the writes are committed at the end of a successful transaction
+func mockInvokeCommittedReads(stub *shim.MockStub, txId string, args [][]byte) pb.Response {
+	transaction := &transactionStub{MockStub: stub, args: args, creatorMspId: AdminMspId, committedReads: true}
+	stub.MockTransactionStart(txId)
+	res := new(SimpleChaincode).Invoke(transaction)
+	if res.Status == shim.OK {
+		err := transaction.commit()
+		if err != nil {
+			res = shim.Error(err.Error())
+		}
+	}
+	stub.MockTransactionEnd(txId)
+	return res
+}
+
+// mockInvokeAs - MockInvoke submitted by a client of the organization creatorMspId
+func mockInvokeAs(stub *shim.MockStub, creatorMspId string, txId string, args [][]byte) pb.Response {
+	stub.MockTransactionStart(txId)
+	res := new(SimpleChaincode).Invoke(&transactionStub{MockStub: stub, args: args, creatorMspId: creatorMspId})
+	stub.MockTransactionEnd(txId)
+	return res
 }
 
 // =====================================================================================================================
@@ -482,12 +686,12 @@ func TestAgentCreation(t *testing.T) {
 
 	checkInvoke(t, mockStub, functionAndArgs)
 
-	agent := &a.Agent{agentId, agentName, agentAddress}
+	agent := &a.Agent{agentId, agentName, agentAddress, getCreatorIdentity(AdminMspId)}
 	agentAsBytes, _ := json.Marshal(agent)
 	// tradeKey, _ := mockStub.CreateCompositeKey("Trade", []string{agentId})
 	checkState(t, mockStub, agentId, string(agentAsBytes))
 
-	expectedResp := "{\"AgentId\":\""+ agentId + "\",\"Name\":\""+ agentName + "\",\"Address\":\""+ agentAddress + "\"}"
+	expectedResp := "{\"AgentId\":\""+ agentId + "\",\"Name\":\""+ agentName + "\",\"Address\":\""+ agentAddress + "\",\"Owner\":\""+ getCreatorIdentity(AdminMspId) + "\"}"
 	checkQuery(t, mockStub, "GetAgentNotFoundError", agentId, expectedResp)
 
 
@@ -517,12 +721,12 @@ func TestExistingAgentCreation(t *testing.T) {
 
 	checkBadInvoke(t, mockStub, functionAndArgs)
 
-	agent := &a.Agent{agentId, agentName, agentAddress}
+	agent := &a.Agent{agentId, agentName, agentAddress, getCreatorIdentity(AdminMspId)}
 	agentAsBytes, _ := json.Marshal(agent)
 	// tradeKey, _ := mockStub.CreateCompositeKey("Trade", []string{agentId})
 	checkState(t, mockStub, agentId, string(agentAsBytes))
 
-	expectedResp := "{\"AgentId\":\""+ agentId + "\",\"Name\":\""+ agentName + "\",\"Address\":\""+ agentAddress + "\"}"
+	expectedResp := "{\"AgentId\":\""+ agentId + "\",\"Name\":\""+ agentName + "\",\"Address\":\""+ agentAddress + "\",\"Owner\":\""+ getCreatorIdentity(AdminMspId) + "\"}"
 	checkQuery(t, mockStub, "GetAgentNotFoundError", agentId, expectedResp)
 }
 // =====================================================================================================================
@@ -553,7 +757,7 @@ func TestServiceAgentRelationCreation(t *testing.T) {
 
 	relationId := serviceId + agentId
 
-	serviceRelationAgent := &a.ServiceRelationAgent{relationId, serviceId, agentId, cost, time}
+	serviceRelationAgent := &a.ServiceRelationAgent{relationId, serviceId, agentId, toCost(cost), toDuration(time)}
 	serviceRealationAgentAsBytes, _ := json.Marshal(serviceRelationAgent)
 	// tradeKey, _ := mockStub.CreateCompositeKey("Trade", []string{agentId})
 	checkState(t, mockStub, relationId, string(serviceRealationAgentAsBytes))
@@ -592,7 +796,7 @@ func TestServiceAndServiceAgentRelationWithStandardValueCreationNewService(t *te
 
 	relationId := serviceId + agentId
 
-	serviceRelationAgent := &a.ServiceRelationAgent{relationId, serviceId, agentId, cost, time}
+	serviceRelationAgent := &a.ServiceRelationAgent{relationId, serviceId, agentId, toCost(cost), toDuration(time)}
 	serviceRealationAgentAsBytes, _ := json.Marshal(serviceRelationAgent)
 	// tradeKey, _ := mockStub.CreateCompositeKey("Trade", []string{serviceName})
 	checkState(t, mockStub, relationId, string(serviceRealationAgentAsBytes))
@@ -630,7 +834,7 @@ func TestServiceAndServiceAgentRelationWithStandardValueExistingService(t *testi
 
 	relationId := serviceId + agentId
 
-	serviceRelationAgent := &a.ServiceRelationAgent{relationId, serviceId, agentId, cost, time}
+	serviceRelationAgent := &a.ServiceRelationAgent{relationId, serviceId, agentId, toCost(cost), toDuration(time)}
 	serviceRealationAgentAsBytes, _ := json.Marshal(serviceRelationAgent)
 	// tradeKey, _ := mockStub.CreateCompositeKey("Trade", []string{serviceName})
 	checkState(t, mockStub, relationId, string(serviceRealationAgentAsBytes))
@@ -670,7 +874,7 @@ func TestServiceAndServiceAgentRelationCreationNewService(t *testing.T) {
 
 	relationId := serviceId + agentId
 
-	serviceRelationAgent := &a.ServiceRelationAgent{relationId, serviceId, agentId, cost, time}
+	serviceRelationAgent := &a.ServiceRelationAgent{relationId, serviceId, agentId, toCost(cost), toDuration(time)}
 	serviceRealationAgentAsBytes, _ := json.Marshal(serviceRelationAgent)
 	// tradeKey, _ := mockStub.CreateCompositeKey("Trade", []string{serviceName})
 	checkState(t, mockStub, relationId, string(serviceRealationAgentAsBytes))
@@ -682,7 +886,7 @@ func TestServiceAndServiceAgentRelationCreationNewService(t *testing.T) {
 
 	reputationId := agentId + serviceId + agentRole
 
-	reputation := &a.Reputation{reputationId, agentId, serviceId, agentRole, initReputationValue}
+	reputation := &a.Reputation{ReputationId: reputationId, AgentId: agentId, ServiceId: serviceId, AgentRole: agentRole, Value: toScore(initReputationValue)}
 	reputationAsBytes, _ := json.Marshal(reputation)
 	// tradeKey, _ := mockStub.CreateCompositeKey("Trade", []string{serviceName})
 	checkState(t, mockStub, reputationId, string(reputationAsBytes))
@@ -722,7 +926,7 @@ func TestServiceAndServiceAgentRelationExistingService(t *testing.T) {
 
 	relationId := serviceId + agentId
 
-	serviceRelationAgent := &a.ServiceRelationAgent{relationId, serviceId, agentId, cost, time}
+	serviceRelationAgent := &a.ServiceRelationAgent{relationId, serviceId, agentId, toCost(cost), toDuration(time)}
 	serviceRealationAgentAsBytes, _ := json.Marshal(serviceRelationAgent)
 	// tradeKey, _ := mockStub.CreateCompositeKey("Trade", []string{serviceName})
 	checkState(t, mockStub, relationId, string(serviceRealationAgentAsBytes))
@@ -734,7 +938,7 @@ func TestServiceAndServiceAgentRelationExistingService(t *testing.T) {
 
 	reputationId := agentId + serviceId + agentRole
 
-	reputation := &a.Reputation{reputationId, agentId, serviceId, agentRole, initReputationValue}
+	reputation := &a.Reputation{ReputationId: reputationId, AgentId: agentId, ServiceId: serviceId, AgentRole: agentRole, Value: toScore(initReputationValue)}
 	reputationAsBytes, _ := json.Marshal(reputation)
 	// tradeKey, _ := mockStub.CreateCompositeKey("Trade", []string{serviceName})
 	checkState(t, mockStub, reputationId, string(reputationAsBytes))
@@ -771,16 +975,21 @@ func TestExecuterActivityCreation(t *testing.T) {
 	functionAndArgs = append(functionAndArgs, functionName)
 	functionAndArgs = append(functionAndArgs,args...)
 
+	completeServiceExecution(t, mockStub, executedServiceTxId, demanderAgentId, executerAgentId, executedServiceId)
 	checkInvoke(t, mockStub, functionAndArgs)
 
 	evaluationId := writerAgentId + demanderAgentId + executerAgentId + executedServiceTxId
 
-	activity := &a.Activity{evaluationId, writerAgentId, demanderAgentId, executerAgentId, executedServiceId,executedServiceTxId,executedServiceTimestamp, activityValue}
+	// the TxTimestamp is the (mock) transaction timestamp, read it back from the state
+	var savedActivity a.Activity
+	json.Unmarshal(mockStub.State[evaluationId], &savedActivity)
+
+	activity := &a.Activity{evaluationId, writerAgentId, demanderAgentId, executerAgentId, executedServiceId,executedServiceTxId,executedServiceTimestamp, toScore(activityValue), savedActivity.TxTimestamp, false}
 	activityAsBytes, _ := json.Marshal(activity)
 	// tradeKey, _ := mockStub.CreateCompositeKey("Trade", []string{demanderAgentId})
 	checkState(t, mockStub, evaluationId, string(activityAsBytes))
 
-	expectedResp := "{\"EvaluationId\":\""+ evaluationId +"\",\"WriterAgentId\":\""+ writerAgentId +"\",\"DemanderAgentId\":\""+ demanderAgentId + "\",\"ExecuterAgentId\":\""+ executerAgentId + "\",\"ExecutedServiceId\":\""+ executedServiceId + "\",\"ExecutedServiceTxid\":\""+ executedServiceTxId + "\",\"ExecutedServiceTimestamp\":\""+ executedServiceTimestamp + "\",\"Value\":\""+ activityValue + "\"}"
+	expectedResp := "{\"EvaluationId\":\""+ evaluationId +"\",\"WriterAgentId\":\""+ writerAgentId +"\",\"DemanderAgentId\":\""+ demanderAgentId + "\",\"ExecuterAgentId\":\""+ executerAgentId + "\",\"ExecutedServiceId\":\""+ executedServiceId + "\",\"ExecutedServiceTxid\":\""+ executedServiceTxId + "\",\"ExecutedServiceTimestamp\":\""+ executedServiceTimestamp + "\",\"Value\":\""+ activityValue + "\",\"TxTimestamp\":\""+ savedActivity.TxTimestamp + "\"}"
 	checkQuery(t, mockStub, GetActivity, evaluationId, expectedResp)
 }
 // =====================================================================================================================
@@ -812,16 +1021,21 @@ func TestDemanderActivityCreation(t *testing.T) {
 	functionAndArgs = append(functionAndArgs, functionName)
 	functionAndArgs = append(functionAndArgs,args...)
 
+	completeServiceExecution(t, mockStub, executedServiceTxId, demanderAgentId, executerAgentId, executedServiceId)
 	checkInvoke(t, mockStub, functionAndArgs)
 
 	evaluationId := writerAgentId + demanderAgentId + executerAgentId + executedServiceTxId
 
-	activity := &a.Activity{evaluationId, writerAgentId, demanderAgentId, executerAgentId, executedServiceId,executedServiceTxId,executedServiceTimestamp, activityValue}
+	// the TxTimestamp is the (mock) transaction timestamp, read it back from the state
+	var savedActivity a.Activity
+	json.Unmarshal(mockStub.State[evaluationId], &savedActivity)
+
+	activity := &a.Activity{evaluationId, writerAgentId, demanderAgentId, executerAgentId, executedServiceId,executedServiceTxId,executedServiceTimestamp, toScore(activityValue), savedActivity.TxTimestamp, false}
 	activityAsBytes, _ := json.Marshal(activity)
 	// tradeKey, _ := mockStub.CreateCompositeKey("Trade", []string{demanderAgentId})
 	checkState(t, mockStub, evaluationId, string(activityAsBytes))
 
-	expectedResp := "{\"EvaluationId\":\""+ evaluationId +"\",\"WriterAgentId\":\""+ writerAgentId +"\",\"DemanderAgentId\":\""+ demanderAgentId + "\",\"ExecuterAgentId\":\""+ executerAgentId + "\",\"ExecutedServiceId\":\""+ executedServiceId + "\",\"ExecutedServiceTxid\":\""+ executedServiceTxId + "\",\"ExecutedServiceTimestamp\":\""+ executedServiceTimestamp + "\",\"Value\":\""+ activityValue + "\"}"
+	expectedResp := "{\"EvaluationId\":\""+ evaluationId +"\",\"WriterAgentId\":\""+ writerAgentId +"\",\"DemanderAgentId\":\""+ demanderAgentId + "\",\"ExecuterAgentId\":\""+ executerAgentId + "\",\"ExecutedServiceId\":\""+ executedServiceId + "\",\"ExecutedServiceTxid\":\""+ executedServiceTxId + "\",\"ExecutedServiceTimestamp\":\""+ executedServiceTimestamp + "\",\"Value\":\""+ activityValue + "\",\"TxTimestamp\":\""+ savedActivity.TxTimestamp + "\"}"
 	checkQuery(t, mockStub, GetActivity, evaluationId, expectedResp)
 }
 
@@ -1057,7 +1271,7 @@ func TestDeleteServiceRelationAgent(t *testing.T) {
 	functionAndArgs2 = append(functionAndArgs2, functionName)
 	functionAndArgs2 = append(functionAndArgs2, args3...)
 
-	expectedRespAfterDelete := "{\"RelationId\":\"\",\"ServiceId\":\"\",\"AgentId\":\"\",\"Cost\":\"\",\"Time\":\"\"}"
+	expectedRespAfterDelete := "{\"RelationId\":\"\",\"ServiceId\":\"\",\"AgentId\":\"\",\"Cost\":\"0\",\"Time\":\"0\"}"
 	checkQuery(t, mockStub, functionName, newServiceRelationAgentId, expectedRespAfterDelete)
 
 	// VERIFY THE QUERY ON THE INDEX AFTER THE DELETE GetServicesByName with the newly created services
@@ -1073,7 +1287,7 @@ func TestDeleteServiceRelationAgent(t *testing.T) {
 	functionAndArgs3 = append(functionAndArgs3, functionNameIndexQuery)
 	functionAndArgs3 = append(functionAndArgs3, args4...)
 
-	expectedRespAfterDeleteOnIndex := "{\"RelationId\":\"\",\"ServiceId\":\"\",\"AgentId\":\"\",\"Cost\":\"\",\"Time\":\"\"}"
+	expectedRespAfterDeleteOnIndex := "{\"RelationId\":\"\",\"ServiceId\":\"\",\"AgentId\":\"\",\"Cost\":\"0\",\"Time\":\"0\"}"
 	checkQuery(t, mockStub, functionName, newServiceRelationAgentId, expectedRespAfterDeleteOnIndex)
 
 	// VERIFY THE QUERY ON THE INDEX AFTER THE DELETE GetServicesByName with the newly created services
@@ -1089,11 +1303,2305 @@ func TestDeleteServiceRelationAgent(t *testing.T) {
 	functionAndArgs4 = append(functionAndArgs4, functionNameIndexGetServicesByAgentQuery)
 	functionAndArgs4 = append(functionAndArgs4, args5...)
 
-	expectedRespAfterDeleteOnIndex2 := "{\"RelationId\":\"\",\"ServiceId\":\"\",\"AgentId\":\"\",\"Cost\":\"\",\"Time\":\"\"}"
+	expectedRespAfterDeleteOnIndex2 := "{\"RelationId\":\"\",\"ServiceId\":\"\",\"AgentId\":\"\",\"Cost\":\"0\",\"Time\":\"0\"}"
 	checkQuery(t, mockStub, functionName, newServiceRelationAgentId, expectedRespAfterDeleteOnIndex2)
 
 }
 
+// =====================================================================================================================
+// TestActivityUpdatesReputation - Test that the 'CreateActivity' function updates the reputation of the evaluated agent
+// =====================================================================================================================
+func TestActivityUpdatesReputation(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Activity Updates Reputation", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+
+	// FIRST EVALUATION OF THE EXECUTER (WRITTEN BY THE DEMANDER)
+	completeServiceExecution(t, mockStub, ExecutedServiceTxId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, WritingDemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, ExecutedServiceTxId, ExecutedServiceTimestamp, "10"})
+
+	agentRole := a.Executer
+	reputationId := ExecuterAgentId + ExecutedServiceId + agentRole
+	checkReputationValue(t, mockStub, reputationId, "10")
+
+	// SECOND EVALUATION OF THE EXECUTER: THE VALUE IS THE MEAN OF THE EVALUATIONS
+	completeServiceExecution(t, mockStub, "execServiceTxId2", DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, WritingDemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execServiceTxId2", ExecutedServiceTimestamp, "5"})
+
+	checkReputationValue(t, mockStub, reputationId, "7.5")
+
+	// THE REPUTATION AS DEMANDER IS NOT TOUCHED
+	demanderReputationId := DemanderAgentId + ExecutedServiceId + a.Demander
+	expectedResp3 := "{\"ReputationId\":\""+ demanderReputationId +"\",\"AgentId\":\""+ DemanderAgentId +"\",\"ServiceId\":\""+ ExecutedServiceId +"\",\"AgentRole\":\""+ a.Demander +"\",\"Value\":\"8\"}"
+	checkQuery(t, mockStub, GetReputation, demanderReputationId, expectedResp3)
+
+	// NOT NUMERIC EVALUATION IS REFUSED
+	completeServiceExecution(t, mockStub, "execServiceTxId3", DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+	checkBadInvoke(t, mockStub, []string{CreateActivity, WritingDemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execServiceTxId3", ExecutedServiceTimestamp, "good"})
+}
+
+// =====================================================================================================================
+// TestModifyReputationValueAdminOnly - Test that the manual reputation setters are allowed only to the administrators
+// =====================================================================================================================
+func TestModifyReputationValueAdminOnly(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Modify Reputation Value Admin Only", simpleChaincode)
+
+	reputationId := ExecuterAgentId + ExecutedServiceId + a.Executer
+
+	// Init without administrators is refused
+	res := mockInit(mockStub, "1", [][]byte{})
+	if res.Status == shim.OK {
+		testLog.Info("Init without administrators unexpectedly succeeded")
+		t.FailNow()
+	}
+
+	// Init with administrators: a client of the administrators organization can override
+	checkInit(t, mockStub, getInitArguments())
+	checkInvoke(t, mockStub, []string{ModifyReputationValue, reputationId, "7"})
+
+	expectedResp := "{\"ReputationId\":\""+ reputationId +"\",\"AgentId\":\""+ ExecuterAgentId +"\",\"ServiceId\":\""+ ExecutedServiceId +"\",\"AgentRole\":\""+ a.Executer +"\",\"Value\":\"7\"}"
+	checkQuery(t, mockStub, GetReputation, reputationId, expectedResp)
+
+	// A client of another organization is not an administrator
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{ModifyReputationValue, reputationId, "8"})
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{ModifyOrCreateReputationValue, ExecuterAgentId, ExecutedServiceId, a.Executer, "8"})
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{CreateReputation, ExistingAgentId, ExistingServiceId, a.Executer, "8"})
+	checkQuery(t, mockStub, GetReputation, reputationId, expectedResp)
+
+	// A saved configuration without administrators refuses everybody
+	var config a.LedgerConfig
+	json.Unmarshal(mockStub.State[a.LedgerConfigId], &config)
+	config.AdminMspIds = []string{}
+	mockStub.State[a.LedgerConfigId], _ = json.Marshal(config)
+	checkBadInvoke(t, mockStub, []string{ModifyReputationValue, reputationId, "8"})
+	checkQuery(t, mockStub, GetReputation, reputationId, expectedResp)
+}
+
+// =====================================================================================================================
+// TestCreateReputationSaveError - Test that a reputation not saved on the ledger is an error of CreateReputation
+// =====================================================================================================================
+func TestCreateReputationSaveError(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Create Reputation Save Error", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+
+	reputationId := ExistingAgentId + ExistingServiceId + a.Executer
+	value, _ := a.ParseScore("8")
+	mockStub.MockTransactionStart("failing")
+	_, err := a.CreateReputation(reputationId, ExistingAgentId, ExistingServiceId, a.Executer, value, &failingStub{MockStub: mockStub, failingKey: reputationId})
+	mockStub.MockTransactionEnd("failing")
+	if err == nil {
+		testLog.Info("CreateReputation did not fail when the reputation was not saved")
+		t.FailNow()
+	}
+}
+
+// =====================================================================================================================
+// TestReputationModels - Test the selection of the reputation model per service and the comparison of the models
+// =====================================================================================================================
+func TestReputationModels(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Reputation Models", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+
+	// UNKNOWN MODEL AND UNKNOWN SERVICE ARE REFUSED
+	checkBadInvoke(t, mockStub, []string{SetReputationModel, "MODE"})
+	checkBadInvoke(t, mockStub, []string{SetReputationModel, a.EwmaModelName, "idserviceNotExisting"})
+
+	// EWMA FOR THE SERVICE (THE GLOBAL MODEL IS STILL THE MEAN)
+	// the evaluations of the demander (DEMANDER reputation 8) have credibility 0.8: alpha = 0.3 * 0.8
+	checkInvoke(t, mockStub, []string{SetReputationModel, a.EwmaModelName, ExecutedServiceId})
+	completeServiceExecution(t, mockStub, ExecutedServiceTxId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, WritingDemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, ExecutedServiceTxId, ExecutedServiceTimestamp, "10"})
+	completeServiceExecution(t, mockStub, "execServiceTxId2", DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, WritingDemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execServiceTxId2", ExecutedServiceTimestamp, "5"})
+
+	agentRole := a.Executer
+	reputationId := ExecuterAgentId + ExecutedServiceId + agentRole
+	checkReputationValue(t, mockStub, reputationId, "8.8")
+
+	// COMPARE THE MODELS ON THE SAME EVALUATIONS (THE SAVED REPUTATION IS NOT TOUCHED)
+	expectedMeanResp := "{\"ReputationId\":\""+ reputationId +"\",\"AgentId\":\""+ ExecuterAgentId +"\",\"ServiceId\":\""+ ExecutedServiceId +"\",\"AgentRole\":\""+ agentRole +"\",\"Value\":\"7.5\"}"
+	checkQueryArgs(t, mockStub, [][]byte{[]byte(ComputeReputationWithModel), []byte(ExecuterAgentId), []byte(ExecutedServiceId), []byte(agentRole), []byte(a.MeanModelName)}, expectedMeanResp)
+	// BETA: positive evidence 0.8 * (1 + 0.5) = 1.2, negative evidence 0.8 * 0.5 = 0.4, 10 * (1.2 + 1) / (1.6 + 2)
+	expectedBetaResp := "{\"ReputationId\":\""+ reputationId +"\",\"AgentId\":\""+ ExecuterAgentId +"\",\"ServiceId\":\""+ ExecutedServiceId +"\",\"AgentRole\":\""+ agentRole +"\",\"Value\":\"6.111111111\"}"
+	checkQueryArgs(t, mockStub, [][]byte{[]byte(ComputeReputationWithModel), []byte(ExecuterAgentId), []byte(ExecutedServiceId), []byte(agentRole), []byte(a.BetaModelName)}, expectedBetaResp)
+	checkReputationValue(t, mockStub, reputationId, "8.8")
+
+	// NO WEIGHT, NO EVALUATIONS: EVERY MODEL REFUSES TO COMPUTE (THE BETA PRIOR IS NOT A REPUTATION)
+	zeroWeightEvaluations := []a.Evaluation{{Value: a.NewDecimal(10)}, {Value: a.NewDecimal(2)}}
+	for _, modelName := range []string{a.MeanModelName, a.EwmaModelName, a.BetaModelName, a.MedianModelName, a.TrimmedMeanModelName} {
+		model, _ := a.NewReputationModel(modelName, a.GetDefaultLedgerConfig())
+		if _, err := model.ComputeReputation(zeroWeightEvaluations); err != a.ErrNoEvaluations {
+			testLog.Info("Model", modelName, "on evaluations without weight returned", err)
+			t.FailNow()
+		}
+	}
+
+	// WRONG PARAMETERS ARE REFUSED
+	checkBadInvoke(t, mockStub, []string{SetReputationModelParameters, "1.5", "0", "10"})
+	checkBadInvoke(t, mockStub, []string{SetReputationModelParameters, "0.5", "10", "0"})
+
+	// THE ADMINISTRATORS ONLY CAN CHANGE THE MODEL
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{SetReputationModel, a.BetaModelName})
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{SetReputationModelParameters, "0.5", "0", "10"})
+}
+
+// =====================================================================================================================
+// TestLedgerConfigNonFiniteValues - NaN and infinite parameters are refused by every setter of the configuration (they
+// pass the range checks and cannot be saved: the configuration and its administrators would be deleted)
+// =====================================================================================================================
+func TestLedgerConfigNonFiniteValues(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Ledger Config Non Finite Values", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+
+	for _, value := range []string{"NaN", "+Inf", "-Inf"} {
+		checkBadInvoke(t, mockStub, []string{SetReputationModelParameters, value, "0", "10"})
+		checkBadInvoke(t, mockStub, []string{SetReputationModelParameters, "0.5", value, "10"})
+		checkBadInvoke(t, mockStub, []string{SetReputationModelParameters, "0.5", "0", value})
+		checkBadInvoke(t, mockStub, []string{SetGlobalTrustParameters, value, DemanderAgentId})
+		checkBadInvoke(t, mockStub, []string{SetCredibilityWeighting, "true", value})
+		checkBadInvoke(t, mockStub, []string{SetRobustAggregationParameters, value, "3"})
+		checkBadInvoke(t, mockStub, []string{SetRobustAggregationParameters, "0.25", value})
+		checkBadInvoke(t, mockStub, []string{SetColdStartWeight, value})
+		checkBadInvoke(t, mockStub, []string{SetDisputedEvaluationWeight, value})
+		checkBadInvoke(t, mockStub, []string{SetSlaPenaltyWeight, value})
+	}
+
+	// THE CONFIGURATION (AND ITS ADMINISTRATORS) IS STILL THERE
+	var config a.LedgerConfig
+	json.Unmarshal(mockStub.State[a.LedgerConfigId], &config)
+	if len(config.AdminMspIds) != 1 || config.AdminMspIds[0] != AdminMspId || config.ScoreMax != a.DefaultScoreMax {
+		testLog.Info("Ledger configuration was", string(mockStub.State[a.LedgerConfigId]))
+		t.FailNow()
+	}
+	checkInvoke(t, mockStub, []string{SetSlaPenaltyWeight, "0.5"})
+}
+
+// =====================================================================================================================
+// TestComputeGlobalTrust - Test the EigenTrust global trust computation on the demander/executer graph
+// =====================================================================================================================
+func TestComputeGlobalTrust(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Compute Global Trust", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+
+	// NO ACTIVITIES: NO AGENTS IN THE GRAPH
+	checkBadInvoke(t, mockStub, []string{ComputeGlobalTrust})
+
+	// THE DEMANDER AND THE EXECUTER EVALUATE EACH OTHER: SYMMETRIC GLOBAL TRUST
+	completeServiceExecution(t, mockStub, ExecutedServiceTxId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, WritingDemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, ExecutedServiceTxId, ExecutedServiceTimestamp, "10"})
+	checkInvoke(t, mockStub, []string{CreateActivity, WritingExecuterAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, ExecutedServiceTxId, ExecutedServiceTimestamp, "10"})
+	checkInvoke(t, mockStub, []string{ComputeGlobalTrust})
+
+	expectedResp := "{\"GlobalTrustId\":\"" + a.GlobalTrustIdPrefix + ExecuterAgentId + "\",\"AgentId\":\"" + ExecuterAgentId + "\",\"Value\":\"0.5\",\"PreTrusted\":false}"
+	checkQuery(t, mockStub, GetGlobalTrust, ExecuterAgentId, expectedResp)
+
+	// PRE-TRUSTED DEMANDER: t98 = 0.85*t99 + 0.15, t99 = 0.85*t98
+	checkBadInvoke(t, mockStub, []string{SetGlobalTrustParameters, "0.15", "idagentNotExisting"})
+	checkBadInvoke(t, mockStub, []string{SetGlobalTrustParameters, "1.5", DemanderAgentId})
+	checkInvoke(t, mockStub, []string{SetGlobalTrustParameters, "0.15", DemanderAgentId})
+
+	res := mockInvoke(mockStub, "1", [][]byte{[]byte(ComputeGlobalTrust)})
+	if res.Status != shim.OK {
+		testLog.Info("ComputeGlobalTrust failed", string(res.Message))
+		t.FailNow()
+	}
+	var globalTrusts []a.GlobalTrust
+	json.Unmarshal(res.Payload, &globalTrusts)
+	// 0.15 / (1 - 0.85*0.85) AND 0.85 * 0.15 / (1 - 0.85*0.85), UP TO THE ITERATION CAP AND THE ROUNDING OF THE DECIMAL
+	expectedValues := map[string]string{DemanderAgentId: "0.540540581", ExecuterAgentId: "0.459459419"}
+	if len(globalTrusts) != 2 {
+		testLog.Info("Global trust computed for", len(globalTrusts), "agents and not 2")
+		t.FailNow()
+	}
+	for _, globalTrust := range globalTrusts {
+		if globalTrust.Value != expectedValues[globalTrust.AgentId] {
+			testLog.Info("Global trust of", globalTrust.AgentId, "was", globalTrust.Value, "and not", expectedValues[globalTrust.AgentId])
+			t.FailNow()
+		}
+		if globalTrust.PreTrusted != (globalTrust.AgentId == DemanderAgentId) {
+			testLog.Info("Wrong pre-trusted flag of", globalTrust.AgentId)
+			t.FailNow()
+		}
+	}
+}
+
+// =====================================================================================================================
+// TestDecayedReputation - Test the time decay of the evaluations (the age is computed from the transaction timestamp)
+// =====================================================================================================================
+func TestDecayedReputation(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Decayed Reputation", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+
+	completeServiceExecution(t, mockStub, ExecutedServiceTxId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, WritingDemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, ExecutedServiceTxId, ExecutedServiceTimestamp, "10"})
+	completeServiceExecution(t, mockStub, "execServiceTxId2", DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, WritingDemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execServiceTxId2", ExecutedServiceTimestamp, "5"})
+
+	// THE FIRST EVALUATION WAS WRITTEN YEARS AGO
+	evaluationId := WritingDemanderAgentId + DemanderAgentId + ExecuterAgentId + ExecutedServiceTxId
+	var oldActivity a.Activity
+	json.Unmarshal(mockStub.State[evaluationId], &oldActivity)
+	if oldActivity.TxTimestamp == "" {
+		testLog.Info("Activity", evaluationId, "without TxTimestamp")
+		t.FailNow()
+	}
+	oldActivity.TxTimestamp = "2000-01-01T00:00:00Z"
+	mockStub.State[evaluationId], _ = json.Marshal(oldActivity)
+
+	// NO HALF LIFE CONFIGURED
+	checkBadQuery(t, mockStub, GetDecayedReputation, ExecuterAgentId)
+	checkBadInvoke(t, mockStub, []string{GetDecayedReputation, ExecuterAgentId, ExecutedServiceId, a.Executer})
+	checkBadInvoke(t, mockStub, []string{SetDecayHalfLife, "one month"})
+
+	// WITH AN HALF LIFE OF 1 HOUR THE OLD EVALUATION DOES NOT COUNT ANYMORE
+	agentRole := a.Executer
+	reputationId := ExecuterAgentId + ExecutedServiceId + agentRole
+	expectedResp := "{\"ReputationId\":\""+ reputationId +"\",\"AgentId\":\""+ ExecuterAgentId +"\",\"ServiceId\":\""+ ExecutedServiceId +"\",\"AgentRole\":\""+ agentRole +"\",\"Value\":\"5\"}"
+	checkQueryArgs(t, mockStub, [][]byte{[]byte(GetDecayedReputation), []byte(ExecuterAgentId), []byte(ExecutedServiceId), []byte(agentRole), []byte("1h")}, expectedResp)
+
+	checkInvoke(t, mockStub, []string{SetDecayHalfLife, "1h"})
+	checkQueryArgs(t, mockStub, [][]byte{[]byte(GetDecayedReputation), []byte(ExecuterAgentId), []byte(ExecutedServiceId), []byte(agentRole)}, expectedResp)
+
+	// THE SAVED REPUTATION IS NOT TOUCHED BY THE QUERY
+	checkReputationValue(t, mockStub, reputationId, "7.5")
+}
+
+// =====================================================================================================================
+// TestReviewerCredibility - Test the weighting of the evaluations by the reputation of the reviewer, in both directions
+// =====================================================================================================================
+func TestReviewerCredibility(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Reviewer Credibility", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+
+	// REVIEWERS: idagent98 DEMANDER 8 on the service, idagent1 DEMANDER 2 on another service, idagent2 without reputation
+	checkInvoke(t, mockStub, []string{CreateReputation, "idagent1", ExistingServiceId, a.Demander, "2"})
+	checkBadInvoke(t, mockStub, []string{SetCredibilityWeighting, "yes"})
+	checkBadInvoke(t, mockStub, []string{SetCredibilityWeighting, "true", "2"})
+	checkInvoke(t, mockStub, []string{SetCredibilityWeighting, "true", "0.2"})
+
+	// EXECUTER EVALUATED BY THE DEMANDERS: (0.8 * 10 + 0.2 * 0) / (0.8 + 0.2)
+	completeServiceExecution(t, mockStub, ExecutedServiceTxId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, WritingDemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, ExecutedServiceTxId, ExecutedServiceTimestamp, "10"})
+	completeServiceExecution(t, mockStub, "execServiceTxId2", "idagent1", ExecuterAgentId, ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, "idagent1", "idagent1", ExecuterAgentId, ExecutedServiceId, "execServiceTxId2", ExecutedServiceTimestamp, "0"})
+
+	executerReputationId := ExecuterAgentId + ExecutedServiceId + a.Executer
+	checkReputationValue(t, mockStub, executerReputationId, "8")
+
+	// DEMANDER EVALUATED BY THE EXECUTERS: idagent99 (EXECUTER 8) and idagent2 (default credibility 0.2)
+	checkInvoke(t, mockStub, []string{CreateActivity, WritingExecuterAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, ExecutedServiceTxId, ExecutedServiceTimestamp, "10"})
+	completeServiceExecution(t, mockStub, "execServiceTxId3", DemanderAgentId, "idagent2", ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, "idagent2", DemanderAgentId, "idagent2", ExecutedServiceId, "execServiceTxId3", ExecutedServiceTimestamp, "0"})
+
+	demanderReputationId := DemanderAgentId + ExecutedServiceId + a.Demander
+	checkReputationValue(t, mockStub, demanderReputationId, "8")
+
+	// WITHOUT CREDIBILITY WEIGHTING THE EVALUATIONS COUNT THE SAME
+	checkInvoke(t, mockStub, []string{SetCredibilityWeighting, "false"})
+	expectedResp3 := "{\"ReputationId\":\""+ demanderReputationId +"\",\"AgentId\":\""+ DemanderAgentId +"\",\"ServiceId\":\""+ ExecutedServiceId +"\",\"AgentRole\":\""+ a.Demander +"\",\"Value\":\"5\"}"
+	checkQueryArgs(t, mockStub, [][]byte{[]byte(ComputeReputationWithModel), []byte(DemanderAgentId), []byte(ExecutedServiceId), []byte(a.Demander), []byte(a.MeanModelName)}, expectedResp3)
+}
+
+// =====================================================================================================================
+// TestDemanderReputationBreakdown - Test the DEMANDER reputation computed from the evaluations written by the executers
+// =====================================================================================================================
+func TestDemanderReputationBreakdown(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Demander Reputation Breakdown", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+
+	// NO EVALUATIONS OF THE DEMANDER YET
+	checkBadInvoke(t, mockStub, []string{GetDemanderReputationBreakdown, DemanderAgentId, ExecutedServiceId})
+
+	// idagent99 (EXECUTER 9, credibility 0.9) and idagent2 (no reputation, credibility 0.5) evaluate the demander
+	completeServiceExecution(t, mockStub, ExecutedServiceTxId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, WritingExecuterAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, ExecutedServiceTxId, ExecutedServiceTimestamp, "10"})
+	completeServiceExecution(t, mockStub, "execServiceTxId2", DemanderAgentId, "idagent2", ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, "idagent2", DemanderAgentId, "idagent2", ExecutedServiceId, "execServiceTxId2", ExecutedServiceTimestamp, "3"})
+	// an evaluation written by the demander does not count for its DEMANDER reputation (the executer stays at 9)
+	checkInvoke(t, mockStub, []string{CreateActivity, WritingDemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, ExecutedServiceTxId, ExecutedServiceTimestamp, "9"})
+
+	res := mockInvoke(mockStub, "1", [][]byte{[]byte(GetDemanderReputationBreakdown), []byte(DemanderAgentId), []byte(ExecutedServiceId)})
+	if res.Status != shim.OK {
+		testLog.Info("GetDemanderReputationBreakdown failed", string(res.Message))
+		t.FailNow()
+	}
+	var breakdown a.ReputationBreakdown
+	json.Unmarshal(res.Payload, &breakdown)
+	if breakdown.AgentRole != a.Demander || breakdown.ReputationModel != a.MeanModelName || len(breakdown.Evaluations) != 2 {
+		testLog.Info("Wrong breakdown", string(res.Payload))
+		t.FailNow()
+	}
+	expectedWeights := map[string]string{ExecuterAgentId: "0.9", "idagent2": "0.5"}
+	for _, evaluation := range breakdown.Evaluations {
+		if evaluation.Weight.String() != expectedWeights[evaluation.Activity.WriterAgentId] {
+			testLog.Info("Weight of the evaluation", evaluation.Activity.EvaluationId, "was", evaluation.Weight)
+			t.FailNow()
+		}
+	}
+
+	// THE SAVED DEMANDER REPUTATION IS THE VALUE OF THE BREAKDOWN: (0.9 * 10 + 0.5 * 3) / 1.4 = 7.5
+	demanderReputationId := DemanderAgentId + ExecutedServiceId + a.Demander
+	checkReputationValue(t, mockStub, demanderReputationId, breakdown.Value)
+	if breakdown.Value != "7.5" {
+		testLog.Info("Breakdown value was", breakdown.Value, "and not 7.5")
+		t.FailNow()
+	}
+}
+
+// =====================================================================================================================
+// TestCompositeServiceReputation - Test the reputation of a composite service aggregated from its components
+// =====================================================================================================================
+func TestCompositeServiceReputation(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Composite Service Reputation", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+
+	// COMPONENTS: idservice1 (idagent1 EXECUTER 5), idservice99 (idagent99 EXECUTER 9), idservice2 (idagent2 EXECUTER 10)
+	checkInvoke(t, mockStub, []string{CreateReputation, "idagent1", "idservice1", a.Executer, "5"})
+	checkInvoke(t, mockStub, []string{CreateReputation, "idagent2", "idservice2", a.Executer, "10"})
+	checkInvoke(t, mockStub, []string{CreateCompositeService, "idservice6", "service6", "composite service 6", "idservice1,idservice99"})
+	checkInvoke(t, mockStub, []string{CreateCompositeService, "idservice7", "service7", "composite service 7", "idservice6,idservice2"})
+	executers := "idservice1:idagent1,idservice99:idagent99,idservice2:idagent2"
+
+	checkCompositeValue := func(args []string, expectedValue string) {
+		res := mockInvoke(mockStub, "1", lib.ParseStringSliceToByteSlice(append([]string{GetCompositeServiceReputation}, args...)))
+		if res.Status != shim.OK {
+			testLog.Info("GetCompositeServiceReputation", args, "failed", res.Message)
+			t.FailNow()
+		}
+		var compositeReputation a.CompositeReputation
+		json.Unmarshal(res.Payload, &compositeReputation)
+		if compositeReputation.Value != expectedValue {
+			testLog.Info("Composite reputation", args, "was", compositeReputation.Value, "and not", expectedValue)
+			t.FailNow()
+		}
+	}
+
+	checkCompositeValue([]string{"idservice6", a.MinAggregation, executers}, "5")
+	checkCompositeValue([]string{"idservice6", a.ProductAggregation, executers}, "4.5")
+	checkCompositeValue([]string{"idservice6", a.WeightedMeanAggregation, executers, "idservice99:3"}, "8")
+
+	// NESTED COMPOSITE SERVICE
+	checkCompositeValue([]string{"idservice7", a.MinAggregation, executers}, "5")
+	checkCompositeValue([]string{"idservice7", a.ProductAggregation, executers}, "4.5")
+	// a leaf service is its own composition
+	checkCompositeValue([]string{"idservice2", a.MinAggregation, executers}, "10")
+
+	// WRONG AGGREGATION, MISSING EXECUTER, WRONG WEIGHT
+	checkBadInvoke(t, mockStub, []string{GetCompositeServiceReputation, "idservice6", "MAX", executers})
+	checkBadInvoke(t, mockStub, []string{GetCompositeServiceReputation, "idservice7", a.MinAggregation, "idservice1:idagent1,idservice99:idagent99"})
+	checkBadInvoke(t, mockStub, []string{GetCompositeServiceReputation, "idservice6", a.WeightedMeanAggregation, executers, "idservice99:-1"})
+
+	// CIRCULAR COMPOSITION
+	checkInvoke(t, mockStub, []string{CreateCompositeService, "idservice8", "service8", "composite service 8", "idservice9"})
+	checkInvoke(t, mockStub, []string{CreateCompositeService, "idservice9", "service9", "composite service 9", "idservice8"})
+	checkBadInvoke(t, mockStub, []string{GetCompositeServiceReputation, "idservice8", a.MinAggregation, executers})
+}
+
+// =====================================================================================================================
+// TestReputationConfidence - Test the evidence of the reputation (count, variance, confidence interval, last update)
+// =====================================================================================================================
+func TestReputationConfidence(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Reputation Confidence", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+
+	// idagent1 evaluates idagent2 (no reputation before the evaluations)
+	reputationId := "idagent2" + ExecutedServiceId + a.Executer
+	getReputation := func() a.Reputation {
+		var reputation a.Reputation
+		json.Unmarshal(mockStub.State[reputationId], &reputation)
+		return reputation
+	}
+
+	// ONE EVALUATION: NO VARIANCE, THE INTERVAL IS THE WHOLE SCORE RANGE
+	completeServiceExecution(t, mockStub, ExecutedServiceTxId, "idagent1", "idagent2", ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, "idagent1", "idagent1", "idagent2", ExecutedServiceId, ExecutedServiceTxId, ExecutedServiceTimestamp, "10"})
+	reputation := getReputation()
+	var activity a.Activity
+	json.Unmarshal(mockStub.State["idagent1idagent1idagent2"+ExecutedServiceTxId], &activity)
+	if reputation.EvidenceCount != 1 || reputation.Variance != "0" || reputation.ConfidenceLow != "0" || reputation.ConfidenceHigh != "10" || reputation.LastUpdated != activity.TxTimestamp {
+		testLog.Info("Wrong evidence of the reputation after one evaluation", string(mockStub.State[reputationId]))
+		t.FailNow()
+	}
+
+	// TWO EVALUATIONS: variance (125 - 15 * 15 / 2) / 1 = 12.5, interval 7.5 +- 1.96 * sqrt(12.5 / 2)
+	completeServiceExecution(t, mockStub, "execServiceTxId2", "idagent1", "idagent2", ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, "idagent1", "idagent1", "idagent2", ExecutedServiceId, "execServiceTxId2", ExecutedServiceTimestamp, "5"})
+	reputation = getReputation()
+	if reputation.EvidenceCount != 2 || reputation.EvidenceSum != "15" || reputation.EvidenceSumOfSquares != "125" || reputation.Variance != "12.5" || reputation.ConfidenceHigh != "10" {
+		testLog.Info("Wrong evidence of the reputation after two evaluations", string(mockStub.State[reputationId]))
+		t.FailNow()
+	}
+	if reputation.ConfidenceLow != "2.6" {
+		testLog.Info("Confidence low was", reputation.ConfidenceLow, "and not 2.6")
+		t.FailNow()
+	}
+
+	// THE EVIDENCE IS RETURNED BY GetReputation AND BY THE RANGE QUERIES
+	res := mockInvoke(mockStub, "1", [][]byte{[]byte(GetReputation), []byte(reputationId)})
+	var queriedReputation a.Reputation
+	json.Unmarshal(res.Payload, &queriedReputation)
+	if queriedReputation != reputation {
+		testLog.Info("GetReputation returned", string(res.Payload))
+		t.FailNow()
+	}
+	res = mockInvoke(mockStub, "1", [][]byte{[]byte(GetReputationsByAgentServiceRole), []byte("idagent2"), []byte(ExecutedServiceId), []byte(a.Executer)})
+	var reputations []a.Reputation
+	json.Unmarshal(res.Payload, &reputations)
+	if len(reputations) != 1 || reputations[0] != reputation {
+		testLog.Info("GetReputationsByAgentServiceRole returned", string(res.Payload))
+		t.FailNow()
+	}
+}
+
+func TestDetectCollusion(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Detect Collusion", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+	checkInvoke(t, mockStub, []string{SetCredibilityWeighting, "false"})
+
+	// THE DEMANDER AND THE EXECUTER GIVE EACH OTHER THE MAXIMUM SCORE TWICE
+	for _, executedServiceTxId := range []string{"execServiceTxId1", "execServiceTxId2"} {
+		completeServiceExecution(t, mockStub, executedServiceTxId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+		checkInvoke(t, mockStub, []string{CreateActivity, WritingDemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, executedServiceTxId, ExecutedServiceTimestamp, "10"})
+		checkInvoke(t, mockStub, []string{CreateActivity, WritingExecuterAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, executedServiceTxId, ExecutedServiceTimestamp, "10"})
+	}
+	checkInvoke(t, mockStub, []string{DetectCollusion})
+
+	reportId := a.SuspicionReportIdPrefix + a.ReciprocalRatingPattern + DemanderAgentId + ExecuterAgentId
+	res := mockInvoke(mockStub, "1", [][]byte{[]byte(GetSuspicionReport), []byte(reportId)})
+	if res.Status != shim.OK {
+		testLog.Info("GetSuspicionReport failed", string(res.Message))
+		t.FailNow()
+	}
+	var suspicionReport a.SuspicionReport
+	json.Unmarshal(res.Payload, &suspicionReport)
+	if len(suspicionReport.EvaluationIds) != 4 || len(suspicionReport.AgentIds) != 2 {
+		testLog.Info("Suspicion report flags", suspicionReport.EvaluationIds, "of", suspicionReport.AgentIds)
+		t.FailNow()
+	}
+
+	// AN HONEST DEMANDER EVALUATES THE EXECUTER: THE FLAGGED EVALUATIONS COUNT UNTIL THE EXCLUSION IS ENABLED
+	completeServiceExecution(t, mockStub, "execServiceTxId3", "idagent1", ExecuterAgentId, ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, "idagent1", "idagent1", ExecuterAgentId, ExecutedServiceId, "execServiceTxId3", ExecutedServiceTimestamp, "4"})
+	executerReputationId := ExecuterAgentId + ExecutedServiceId + a.Executer
+	checkReputationValue(t, mockStub, executerReputationId, "8")
+
+	checkBadInvoke(t, mockStub, []string{SetCollusionDetectionParameters, "true", "2", "2", "1h", "3"})
+	checkBadInvoke(t, mockStub, []string{SetCollusionDetectionParameters, "true", "2", "5", "soon", "3"})
+	checkInvoke(t, mockStub, []string{SetCollusionDetectionParameters, "true", "2", "5", "1h", "3"})
+	expectedResp := "{\"ReputationId\":\"" + executerReputationId + "\",\"AgentId\":\"" + ExecuterAgentId + "\",\"ServiceId\":\"" + ExecutedServiceId + "\",\"AgentRole\":\"" + a.Executer + "\",\"Value\":\"4\"}"
+	checkQueryArgs(t, mockStub, [][]byte{[]byte(ComputeReputationWithModel), []byte(ExecuterAgentId), []byte(ExecutedServiceId), []byte(a.Executer), []byte(a.MeanModelName)}, expectedResp)
+
+	// THE EXECUTER RECEIVED 3 RATINGS WITHIN AN HOUR: RATING BURST
+	checkInvoke(t, mockStub, []string{DetectCollusion})
+	res = mockInvoke(mockStub, "1", [][]byte{[]byte(GetSuspicionReportsByAgent), []byte(ExecuterAgentId)})
+	if res.Status != shim.OK {
+		testLog.Info("GetSuspicionReportsByAgent failed", string(res.Message))
+		t.FailNow()
+	}
+	var suspicionReports []a.SuspicionReport
+	json.Unmarshal(res.Payload, &suspicionReports)
+	if len(suspicionReports) != 2 || suspicionReports[0].Pattern != a.RatingBurstPattern || len(suspicionReports[0].EvaluationIds) != 3 {
+		testLog.Info("Suspicion reports of", ExecuterAgentId, "were", string(res.Payload))
+		t.FailNow()
+	}
+}
+
+func TestRobustAggregation(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Robust Aggregation", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+	checkInvoke(t, mockStub, []string{SetCredibilityWeighting, "false"})
+
+	// THREE DEMANDERS GIVE 10, A MALICIOUS ONE GIVES 0: THE MEAN DROPS TO 7.5
+	for _, demanderAgentId := range []string{"idagent1", "idagent2", "idagent3"} {
+		completeServiceExecution(t, mockStub, ExecutedServiceTxId+demanderAgentId, demanderAgentId, ExecuterAgentId, ExecutedServiceId)
+		checkInvoke(t, mockStub, []string{CreateActivity, demanderAgentId, demanderAgentId, ExecuterAgentId, ExecutedServiceId, ExecutedServiceTxId + demanderAgentId, ExecutedServiceTimestamp, "10"})
+	}
+	completeServiceExecution(t, mockStub, "execServiceTxId4", "idagent4", ExecuterAgentId, ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, "idagent4", "idagent4", ExecuterAgentId, ExecutedServiceId, "execServiceTxId4", ExecutedServiceTimestamp, "0"})
+
+	agentRole := a.Executer
+	reputationId := ExecuterAgentId + ExecutedServiceId + agentRole
+	checkReputationValue(t, mockStub, reputationId, "7.5")
+
+	// MEDIAN AND TRIMMED MEAN (A QUARTER OF THE WEIGHT CUT FROM EACH END) IGNORE THE 0
+	expectedResp := "{\"ReputationId\":\"" + reputationId + "\",\"AgentId\":\"" + ExecuterAgentId + "\",\"ServiceId\":\"" + ExecutedServiceId + "\",\"AgentRole\":\"" + agentRole + "\",\"Value\":\"10\"}"
+	checkQueryArgs(t, mockStub, [][]byte{[]byte(ComputeReputationWithModel), []byte(ExecuterAgentId), []byte(ExecutedServiceId), []byte(agentRole), []byte(a.MedianModelName)}, expectedResp)
+	checkBadInvoke(t, mockStub, []string{SetRobustAggregationParameters, "0.5", "3"})
+	checkBadInvoke(t, mockStub, []string{SetRobustAggregationParameters, "0.25", "0"})
+	checkInvoke(t, mockStub, []string{SetRobustAggregationParameters, "0.25", "3"})
+	checkQueryArgs(t, mockStub, [][]byte{[]byte(ComputeReputationWithModel), []byte(ExecuterAgentId), []byte(ExecutedServiceId), []byte(agentRole), []byte(a.TrimmedMeanModelName)}, expectedResp)
+
+	// MAD FILTER FOR THE SERVICE: THE 0 IS AN OUTLIER, KEPT IN THE BREAKDOWN AS EXCLUDED
+	checkBadInvoke(t, mockStub, []string{SetOutlierFilter, "IQR", ExecutedServiceId})
+	checkInvoke(t, mockStub, []string{SetOutlierFilter, a.MadOutlierFilterName, ExecutedServiceId})
+	checkBadInvoke(t, mockStub, []string{GetReputationBreakdown, ExecuterAgentId, ExecutedServiceId, "WRITER"})
+
+	res := mockInvoke(mockStub, "1", [][]byte{[]byte(GetReputationBreakdown), []byte(ExecuterAgentId), []byte(ExecutedServiceId), []byte(agentRole)})
+	if res.Status != shim.OK {
+		testLog.Info("GetReputationBreakdown failed", string(res.Message))
+		t.FailNow()
+	}
+	var breakdown a.ReputationBreakdown
+	json.Unmarshal(res.Payload, &breakdown)
+	if breakdown.Value != "10" || len(breakdown.Evaluations) != 4 {
+		testLog.Info("Reputation breakdown was", string(res.Payload))
+		t.FailNow()
+	}
+	for _, evaluation := range breakdown.Evaluations {
+		isOutlier := evaluation.Activity.WriterAgentId == "idagent4"
+		if evaluation.Excluded != isOutlier || (isOutlier && evaluation.ExclusionReason != a.OutlierExclusion) {
+			testLog.Info("Wrong exclusion of the evaluation", evaluation.Activity.EvaluationId)
+			t.FailNow()
+		}
+	}
+}
+
+func TestRecomputeReputations(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Recompute Reputations", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+	checkInvoke(t, mockStub, []string{SetCredibilityWeighting, "false"})
+
+	// idagent1 evaluates idagent2 twice, then the reputation drifts with a manual override
+	reputationId := "idagent2" + ExecutedServiceId + a.Executer
+	completeServiceExecution(t, mockStub, ExecutedServiceTxId, "idagent1", "idagent2", ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, "idagent1", "idagent1", "idagent2", ExecutedServiceId, ExecutedServiceTxId, ExecutedServiceTimestamp, "10"})
+	completeServiceExecution(t, mockStub, "execServiceTxId2", "idagent1", "idagent2", ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, "idagent1", "idagent1", "idagent2", ExecutedServiceId, "execServiceTxId2", ExecutedServiceTimestamp, "5"})
+	var replayedReputation a.Reputation
+	json.Unmarshal(mockStub.State[reputationId], &replayedReputation)
+	checkInvoke(t, mockStub, []string{ModifyReputationValue, reputationId, "3"})
+	// indexed reputation without activities
+	manualReputationId := "idagent3" + ExistingServiceId + a.Demander
+	checkInvoke(t, mockStub, []string{CreateReputation, "idagent3", ExistingServiceId, a.Demander, "4"})
+	manualReputationAsBytes := mockStub.State[manualReputationId]
+
+	// DRY RUN: DIFF ONLY
+	checkBadInvoke(t, mockStub, []string{RecomputeReputations, "maybe"})
+	res := mockInvoke(mockStub, "1", [][]byte{[]byte(RecomputeReputations), []byte("true")})
+	if res.Status != shim.OK {
+		testLog.Info("RecomputeReputations failed", string(res.Message))
+		t.FailNow()
+	}
+	var reputationDiffs []a.ReputationDiff
+	json.Unmarshal(res.Payload, &reputationDiffs)
+	if len(reputationDiffs) != 2 || reputationDiffs[0].ReputationId != reputationId || reputationDiffs[0].OldValue != "3" || reputationDiffs[0].NewValue != "7.5" || reputationDiffs[0].EvidenceCount != 2 || reputationDiffs[0].Action != a.UpdatedReputationAction || reputationDiffs[1].Action != a.NotRecomputedReputationAction {
+		testLog.Info("Recompute dry run returned", string(res.Payload))
+		t.FailNow()
+	}
+	// THE REPUTATION WITHOUT ACTIVITIES IS REPORTED AND KEPT AS IT IS
+	if reputationDiffs[1].ReputationId != manualReputationId || reputationDiffs[1].OldValue != "4" || reputationDiffs[1].NewValue != "4" || reputationDiffs[1].Error == "" {
+		testLog.Info("Recompute dry run of the reputation without activities returned", reputationDiffs[1])
+		t.FailNow()
+	}
+	checkReputationValue(t, mockStub, reputationId, "3")
+
+	// RECOMPUTATION: THE REPUTATION IS THE SAME AS BEFORE THE OVERRIDE (VALUE AND EVIDENCE)
+	checkInvoke(t, mockStub, []string{RecomputeReputations})
+	var reputation a.Reputation
+	json.Unmarshal(mockStub.State[reputationId], &reputation)
+	if reputation != replayedReputation {
+		testLog.Info("Recomputed reputation was", string(mockStub.State[reputationId]))
+		t.FailNow()
+	}
+	if string(mockStub.State[manualReputationId]) != string(manualReputationAsBytes) {
+		testLog.Info("Reputation without activities was", string(mockStub.State[manualReputationId]))
+		t.FailNow()
+	}
+
+	// NOTHING CHANGES ON A SECOND RECOMPUTATION
+	res = mockInvoke(mockStub, "1", [][]byte{[]byte(RecomputeReputations), []byte("true")})
+	json.Unmarshal(res.Payload, &reputationDiffs)
+	if reputationDiffs[0].Action != a.UnchangedReputationAction {
+		testLog.Info("Second recompute returned", string(res.Payload))
+		t.FailNow()
+	}
+}
+
+// =====================================================================================================================
+// TestRecomputeAfterActivityFlow - Test that replaying the activities right after they are written changes nothing: the
+// replay follows the order of writing (not the order of the index scan nor the client timestamps)
+// =====================================================================================================================
+func TestRecomputeAfterActivityFlow(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Recompute After Activity Flow", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+	checkInvoke(t, mockStub, []string{SetCredibilityWeighting, "false"})
+	checkInvoke(t, mockStub, []string{SetReputationModel, a.EwmaModelName, ExecutedServiceId})
+
+	// EVALUATIONS OF BOTH ROLES, WITH THE CLIENT TIMESTAMPS IN REVERSE ORDER OF WRITING
+	activities := [][]string{
+		{CreateActivity, WritingDemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execServiceTxId1", "2018-03-03T00:00:00Z", "10"},
+		{CreateActivity, WritingExecuterAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execServiceTxId1", "2018-03-03T00:00:00Z", "8"},
+		{CreateActivity, WritingDemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execServiceTxId2", "2018-02-02T00:00:00Z", "2"},
+		{CreateActivity, WritingDemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execServiceTxId3", "2018-01-01T00:00:00Z", "6"},
+		{CreateActivity, "idagent1", "idagent1", ExecuterAgentId, ExecutedServiceId, "execServiceTxId4", "2018-01-01T00:00:00Z", "9"},
+	}
+	for i, activity := range activities {
+		completeServiceExecution(t, mockStub, activity[5], activity[2], activity[3], activity[4])
+		res := mockInvoke(mockStub, "tx"+strconv.Itoa(i), lib.ParseStringSliceToByteSlice(activity))
+		if res.Status != shim.OK {
+			testLog.Info("CreateActivity failed", res.Message)
+			t.FailNow()
+		}
+	}
+	reputationIds := []string{ExecuterAgentId + ExecutedServiceId + a.Executer, DemanderAgentId + ExecutedServiceId + a.Demander}
+	savedReputations := make(map[string]string)
+	for _, reputationId := range reputationIds {
+		savedReputations[reputationId] = string(mockStub.State[reputationId])
+	}
+
+	// DRY RUN: NO REPUTATION CREATED OR UPDATED
+	res := mockInvoke(mockStub, "1", [][]byte{[]byte(RecomputeReputations), []byte("true")})
+	if res.Status != shim.OK {
+		testLog.Info("RecomputeReputations failed", string(res.Message))
+		t.FailNow()
+	}
+	var reputationDiffs []a.ReputationDiff
+	json.Unmarshal(res.Payload, &reputationDiffs)
+	actions := make(map[string]string)
+	for _, reputationDiff := range reputationDiffs {
+		actions[reputationDiff.ReputationId] = reputationDiff.Action
+		if reputationDiff.Action != a.UnchangedReputationAction && reputationDiff.Action != a.NotRecomputedReputationAction {
+			testLog.Info("Recompute dry run returned", string(res.Payload))
+			t.FailNow()
+		}
+	}
+	for _, reputationId := range reputationIds {
+		if actions[reputationId] != a.UnchangedReputationAction {
+			testLog.Info("Recompute dry run returned", string(res.Payload))
+			t.FailNow()
+		}
+	}
+
+	// RECOMPUTATION: THE SAME REPUTATIONS (VALUE AND EVIDENCE)
+	checkInvoke(t, mockStub, []string{RecomputeReputations})
+	for _, reputationId := range reputationIds {
+		if string(mockStub.State[reputationId]) != savedReputations[reputationId] {
+			testLog.Info("Recomputed reputation was", string(mockStub.State[reputationId]), "and not", savedReputations[reputationId])
+			t.FailNow()
+		}
+	}
+}
+
+// =====================================================================================================================
+// TestRecomputeAfterSlaBreach - the SLA penalties change the value and not the evidence of the reputation: the
+// recomputation after evaluations and SLA breaches leaves the reputation of the executer (and of the agent) unchanged
+// =====================================================================================================================
+func TestRecomputeAfterSlaBreach(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Recompute After Sla Breach", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+	checkInvoke(t, mockStub, []string{SetCredibilityWeighting, "false"})
+	checkInvoke(t, mockStub, []string{Mint, DemanderAgentId, "30"})
+	checkInvokeTx := func(txId string, functionAndArgs []string) {
+		res := mockInvoke(mockStub, txId, lib.ParseStringSliceToByteSlice(functionAndArgs))
+		if res.Status != shim.OK {
+			testLog.Info("Invoke", functionAndArgs, "failed", string(res.Message))
+			t.FailNow()
+		}
+	}
+	// promised: cost 5, time 7 (relation idservice99idagent99), evaluated by the demander after the completion
+	executeService := func(executionId string, actualCost string, deliveredTimestamp string, value string) {
+		checkInvokeTx(executionId, []string{RequestServiceExecution, DemanderAgentId, ExecuterAgentId, ExecutedServiceId})
+		checkInvokeTx("accept"+executionId, []string{AcceptServiceExecution, executionId, ExecuterAgentId})
+		checkInvokeTx("deliver"+executionId, []string{DeliverServiceExecution, executionId, ExecuterAgentId, actualCost})
+		var execution a.ServiceExecution
+		json.Unmarshal(mockStub.State[executionId], &execution)
+		execution.AcceptedTimestamp = "2018-07-23T06:00:00Z"
+		execution.DeliveredTimestamp = deliveredTimestamp
+		mockStub.State[executionId], _ = json.Marshal(execution)
+		checkInvokeTx("complete"+executionId, []string{CompleteServiceExecution, executionId, DemanderAgentId})
+		checkInvokeTx("evaluate"+executionId, []string{CreateActivity, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, executionId, ExecutedServiceTimestamp, value})
+	}
+
+	// COMPLIANT, LATE (PENALTY 7), LATE AND OVER THE AGREED COST (PENALTY 5)
+	executeService("execution1", "5", "2018-07-23T12:00:00Z", "9")
+	executeService("execution2", "5", "2018-07-23T16:00:00Z", "6")
+	executeService("execution3", "10", "2018-07-23T16:00:00Z", "8")
+	reputationId := ExecuterAgentId + ExecutedServiceId + a.Executer
+	var reputation a.Reputation
+	json.Unmarshal(mockStub.State[reputationId], &reputation)
+	if reputation.Value.String() != "7" || reputation.EvidenceCount != 3 {
+		testLog.Info("Reputation after the SLA breaches was", string(mockStub.State[reputationId]))
+		t.FailNow()
+	}
+	agentReputationId := a.AgentReputationIdPrefix + ExecuterAgentId + a.Executer
+	savedReputation := string(mockStub.State[reputationId])
+	var savedAgentReputation a.AgentReputation
+	json.Unmarshal(mockStub.State[agentReputationId], &savedAgentReputation)
+
+	// DRY RUN: UNCHANGED
+	res := mockInvoke(mockStub, "1", [][]byte{[]byte(RecomputeReputations), []byte("true")})
+	if res.Status != shim.OK {
+		testLog.Info("RecomputeReputations failed", string(res.Message))
+		t.FailNow()
+	}
+	var reputationDiffs []a.ReputationDiff
+	json.Unmarshal(res.Payload, &reputationDiffs)
+	for _, reputationDiff := range reputationDiffs {
+		if reputationDiff.ReputationId == reputationId && reputationDiff.Action != a.UnchangedReputationAction {
+			testLog.Info("Recompute dry run returned", string(res.Payload))
+			t.FailNow()
+		}
+	}
+
+	// RECOMPUTATION: THE SAME REPUTATION (VALUE AND EVIDENCE), THE SAME WEIGHT IN THE REPUTATION OF THE AGENT
+	checkInvoke(t, mockStub, []string{RecomputeReputations})
+	if string(mockStub.State[reputationId]) != savedReputation {
+		testLog.Info("Recomputed reputation was", string(mockStub.State[reputationId]), "and not", savedReputation)
+		t.FailNow()
+	}
+	var agentReputation a.AgentReputation
+	json.Unmarshal(mockStub.State[agentReputationId], &agentReputation)
+	if agentReputation.Value != savedAgentReputation.Value || agentReputation.EvaluationCount != savedAgentReputation.EvaluationCount || agentReputation.Services[ExecutedServiceId] != savedAgentReputation.Services[ExecutedServiceId] {
+		testLog.Info("Recomputed agent reputation was", string(mockStub.State[agentReputationId]))
+		t.FailNow()
+	}
+}
+
+func TestReputationSnapshots(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Reputation Snapshots", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+	checkInvoke(t, mockStub, []string{SetCredibilityWeighting, "false"})
+
+	// EPOCH 1: idagent2 EXECUTER 10
+	completeServiceExecution(t, mockStub, ExecutedServiceTxId, "idagent1", "idagent2", ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, "idagent1", "idagent1", "idagent2", ExecutedServiceId, ExecutedServiceTxId, ExecutedServiceTimestamp, "10"})
+	checkInvoke(t, mockStub, []string{SnapshotReputations, "epoch1"})
+	checkBadInvoke(t, mockStub, []string{SnapshotReputations, "epoch1"})
+
+	// EPOCH 2: idagent2 EXECUTER 7.5, idagent3 EXECUTER 6
+	completeServiceExecution(t, mockStub, "execServiceTxId2", "idagent1", "idagent2", ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, "idagent1", "idagent1", "idagent2", ExecutedServiceId, "execServiceTxId2", ExecutedServiceTimestamp, "5"})
+	completeServiceExecution(t, mockStub, "execServiceTxId3", "idagent1", "idagent3", ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, "idagent1", "idagent1", "idagent3", ExecutedServiceId, "execServiceTxId3", ExecutedServiceTimestamp, "6"})
+	checkInvoke(t, mockStub, []string{SnapshotReputations, "epoch2"})
+
+	// THE REPUTATION OF THE AGENT AT EVERY EPOCH
+	for epochId, expectedValue := range map[string]string{"epoch1": "10", "epoch2": "7.5"} {
+		res := mockInvoke(mockStub, "1", [][]byte{[]byte(GetReputationsAtEpoch), []byte(epochId), []byte("idagent2"), []byte(ExecutedServiceId), []byte(a.Executer)})
+		var snapshots []a.ReputationSnapshot
+		json.Unmarshal(res.Payload, &snapshots)
+		if res.Status != shim.OK || len(snapshots) != 1 || snapshots[0].Value != expectedValue || snapshots[0].EpochId != epochId {
+			testLog.Info("Reputations of idagent2 at", epochId, "were", string(res.Payload), string(res.Message))
+			t.FailNow()
+		}
+	}
+	checkBadInvoke(t, mockStub, []string{GetReputationsAtEpoch, "epochNotExisting", "idagent2"})
+
+	// DIFF OF THE TWO EPOCHS
+	res := mockInvoke(mockStub, "1", [][]byte{[]byte(DiffReputationEpochs), []byte("epoch1"), []byte("epoch2")})
+	var epochDiffs []a.ReputationEpochDiff
+	json.Unmarshal(res.Payload, &epochDiffs)
+	expectedDiffs := []a.ReputationEpochDiff{
+		{ReputationId: "idagent2" + ExecutedServiceId + a.Executer, AgentId: "idagent2", ServiceId: ExecutedServiceId, AgentRole: a.Executer, FromValue: "10", ToValue: "7.5", Delta: "-2.5"},
+		{ReputationId: "idagent3" + ExecutedServiceId + a.Executer, AgentId: "idagent3", ServiceId: ExecutedServiceId, AgentRole: a.Executer, FromValue: "", ToValue: "6", Delta: ""},
+	}
+	if len(epochDiffs) != len(expectedDiffs) || epochDiffs[0] != expectedDiffs[0] || epochDiffs[1] != expectedDiffs[1] {
+		testLog.Info("Diff of the epochs was", string(res.Payload))
+		t.FailNow()
+	}
+}
+
+func TestTopExecutersForService(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Top Executers For Service", simpleChaincode)
+
+	// THE ENCODING SORTS THE HIGHEST VALUE FIRST
+	values := []string{"100", "10", "9.5", "0.5", "0", "-0.5", "-2"}
+	for i := 1; i < len(values); i++ {
+		previous, _ := a.EncodeReputationValue(values[i-1])
+		current, _ := a.EncodeReputationValue(values[i])
+		if len(previous) != 16 || previous >= current {
+			testLog.Info("Wrong encoding order of", values[i-1], previous, "and", values[i], current)
+			t.FailNow()
+		}
+	}
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+	for agentId, value := range map[string]string{"idagent1": "7", "idagent2": "9.5", "idagent3": "10", "idagent4": "7", "idagent5": "0.5"} {
+		checkInvoke(t, mockStub, []string{CreateReputation, agentId, ExistingServiceId, a.Executer, value})
+	}
+	checkInvoke(t, mockStub, []string{CreateReputation, "idagent5", ExistingServiceId, a.Demander, "10"})
+
+	getTopAgentIds := func(k string) []string {
+		res := mockInvoke(mockStub, "1", [][]byte{[]byte(GetTopExecutersForService), []byte(ExistingServiceId), []byte(k)})
+		if res.Status != shim.OK {
+			testLog.Info("GetTopExecutersForService failed", string(res.Message))
+			t.FailNow()
+		}
+		var reputations []a.Reputation
+		json.Unmarshal(res.Payload, &reputations)
+		var agentIds []string
+		for _, reputation := range reputations {
+			agentIds = append(agentIds, reputation.AgentId)
+		}
+		return agentIds
+	}
+
+	// TOP 3: TIES IN ORDER OF AGENT ID, THE DEMANDER REPUTATION IS NOT RANKED AS EXECUTER
+	if topAgentIds := strings.Join(getTopAgentIds("3"), ","); topAgentIds != "idagent3,idagent2,idagent1" {
+		testLog.Info("Top 3 executers were", topAgentIds)
+		t.FailNow()
+	}
+
+	// THE INDEX FOLLOWS THE REPUTATION CHANGES (NO STALE ENTRY FOR THE OLD VALUE)
+	checkInvoke(t, mockStub, []string{ModifyReputationValue, "idagent5" + ExistingServiceId + a.Executer, "9.9"})
+	if topAgentIds := strings.Join(getTopAgentIds("10"), ","); topAgentIds != "idagent3,idagent5,idagent2,idagent1,idagent4" {
+		testLog.Info("Top executers were", topAgentIds)
+		t.FailNow()
+	}
+
+	checkBadInvoke(t, mockStub, []string{GetTopExecutersForService, ExistingServiceId, "0"})
+	checkBadInvoke(t, mockStub, []string{GetTopExecutersForService, ExistingServiceId, "3", "WRITER"})
+}
+
+func TestSelectExecuter(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Select Executer", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+	checkInvoke(t, mockStub, []string{CreateServiceAgentRelation, ExistingServiceId, "idagent1", "10", "5"})
+	checkInvoke(t, mockStub, []string{CreateServiceAgentRelation, ExistingServiceId, "idagent2", "5", "10"})
+	checkInvoke(t, mockStub, []string{CreateServiceAgentRelation, ExistingServiceId, "idagent3", "20", "1"})
+	checkInvoke(t, mockStub, []string{CreateReputation, "idagent1", ExistingServiceId, a.Executer, "8"})
+	checkInvoke(t, mockStub, []string{CreateReputation, "idagent2", ExistingServiceId, a.Executer, "6"})
+
+	selectExecuter := func(args ...string) a.ExecuterSelection {
+		invokeArgs := [][]byte{[]byte(SelectExecuter)}
+		for _, arg := range args {
+			invokeArgs = append(invokeArgs, []byte(arg))
+		}
+		res := mockInvoke(mockStub, "1", invokeArgs)
+		if res.Status != shim.OK {
+			testLog.Info("SelectExecuter failed", string(res.Message))
+			t.FailNow()
+		}
+		var selection a.ExecuterSelection
+		json.Unmarshal(res.Payload, &selection)
+		return selection
+	}
+	getRanking := func(selection a.ExecuterSelection) string {
+		var agentIds []string
+		for _, candidate := range selection.Candidates {
+			agentIds = append(agentIds, candidate.AgentId)
+		}
+		return strings.Join(agentIds, ",")
+	}
+
+	// ONLY REPUTATION: THE AGENT WITHOUT REPUTATION HAS THE MINIMUM
+	selection := selectExecuter(ExistingServiceId, "reputation:1")
+	if ranking := getRanking(selection); ranking != "idagent1,idagent2,idagent3" {
+		testLog.Info("Ranking by reputation was", ranking)
+		t.FailNow()
+	}
+	if selection.Candidates[0].Rank != 1 || selection.Candidates[0].Score.String() != "0.8" || !selection.Candidates[2].NormalizedReputation.IsZero() {
+		testLog.Info("Wrong score breakdown", selection.Candidates)
+		t.FailNow()
+	}
+
+	// ONLY COST: MIN-MAX OVER THE CANDIDATES, THE CHEAPEST IS 1
+	selection = selectExecuter(ExistingServiceId, "cost:2")
+	if ranking := getRanking(selection); ranking != "idagent2,idagent1,idagent3" {
+		testLog.Info("Ranking by cost was", ranking)
+		t.FailNow()
+	}
+	if selection.Candidates[1].NormalizedCost.String() != "0.666666667" || selection.Weights[a.CostCriterion].String() != "1" {
+		testLog.Info("Wrong cost normalization", selection.Candidates[1], selection.Weights)
+		t.FailNow()
+	}
+
+	// WEIGHTED: 0.5*0.8 + 0.5*(10/19) FOR idagent1, 0.5*0 + 0.5*1 FOR idagent3
+	selection = selectExecuter(ExistingServiceId, "reputation:1,time:1")
+	if ranking := getRanking(selection); ranking != "idagent1,idagent3,idagent2" {
+		testLog.Info("Weighted ranking was", ranking)
+		t.FailNow()
+	}
+
+	// HARD CONSTRAINTS FILTER BEFORE THE RANKING, THE REJECTED ARE REPORTED
+	selection = selectExecuter(ExistingServiceId, "reputation:1,cost:1", "maxCost:15,minReputation:7")
+	if ranking := getRanking(selection); ranking != "idagent1" || len(selection.Rejected) != 2 {
+		testLog.Info("Constrained selection was", ranking, selection.Rejected)
+		t.FailNow()
+	}
+	if selection.Candidates[0].NormalizedCost.String() != "1" {
+		testLog.Info("A single candidate should have the best cost", selection.Candidates[0])
+		t.FailNow()
+	}
+
+	// WRONG INPUTS
+	checkBadInvoke(t, mockStub, []string{SelectExecuter, ExistingServiceId, "price:1"})
+	checkBadInvoke(t, mockStub, []string{SelectExecuter, ExistingServiceId, "reputation:0"})
+	checkBadInvoke(t, mockStub, []string{SelectExecuter, ExistingServiceId, "reputation:-1,cost:2"})
+	checkBadInvoke(t, mockStub, []string{SelectExecuter, ExistingServiceId, "reputation:1", "maxPrice:3"})
+	checkBadInvoke(t, mockStub, []string{SelectExecuter, "idserviceNotExisting", "reputation:1"})
+	checkBadInvoke(t, mockStub, []string{SelectExecuter, ExistingServiceId})
+}
+
+func TestPlanCompositeExecution(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Plan Composite Execution", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+	checkInvoke(t, mockStub, []string{CreateCompositeService, "idservice6", "service6", "composite service 6", "idservice1,idservice2"})
+	checkInvoke(t, mockStub, []string{CreateServiceAgentRelation, "idservice1", "idagent1", "10", "5"})
+	checkInvoke(t, mockStub, []string{CreateServiceAgentRelation, "idservice1", "idagent2", "4", "9"})
+	checkInvoke(t, mockStub, []string{CreateServiceAgentRelation, "idservice2", "idagent3", "6", "2"})
+	checkInvoke(t, mockStub, []string{CreateServiceAgentRelation, "idservice2", "idagent4", "3", "8"})
+	checkInvoke(t, mockStub, []string{CreateReputation, "idagent1", "idservice1", a.Executer, "8"})
+	checkInvoke(t, mockStub, []string{CreateReputation, "idagent2", "idservice1", a.Executer, "6"})
+	checkInvoke(t, mockStub, []string{CreateReputation, "idagent3", "idservice2", a.Executer, "9"})
+
+	planExecution := func(txId string, args ...string) a.ExecutionPlan {
+		invokeArgs := [][]byte{[]byte(PlanCompositeExecution)}
+		for _, arg := range args {
+			invokeArgs = append(invokeArgs, []byte(arg))
+		}
+		res := mockInvoke(mockStub, txId, invokeArgs)
+		if res.Status != shim.OK {
+			testLog.Info("PlanCompositeExecution failed", string(res.Message))
+			t.FailNow()
+		}
+		var plan a.ExecutionPlan
+		json.Unmarshal(res.Payload, &plan)
+		return plan
+	}
+	getExecuters := func(plan a.ExecutionPlan) string {
+		var agentIds []string
+		for _, step := range plan.Steps {
+			agentIds = append(agentIds, step.ServiceId+":"+step.AgentId)
+		}
+		return strings.Join(agentIds, ",")
+	}
+
+	// MINIMUM COST: THE CHEAPEST EXECUTER OF EVERY COMPONENT
+	plan := planExecution("plan1", "idservice6", a.CostObjective, "0", "100")
+	if executers := getExecuters(plan); executers != "idservice1:idagent2,idservice2:idagent4" || plan.TotalCost.String() != "7" || plan.TotalTime.String() != "17" {
+		testLog.Info("Minimum cost plan was", executers, plan.TotalCost, plan.TotalTime)
+		t.FailNow()
+	}
+
+	// MINIMUM TIME
+	plan = planExecution("plan2", "idservice6", a.TimeObjective, "0", "100")
+	if executers := getExecuters(plan); executers != "idservice1:idagent1,idservice2:idagent3" || plan.TotalTime.String() != "7" {
+		testLog.Info("Minimum time plan was", executers, plan.TotalTime)
+		t.FailNow()
+	}
+
+	// MINIMUM TIME WITHIN THE BUDGET: THE FASTEST COMBINATION COSTS 16
+	plan = planExecution("plan3", "idservice6", a.TimeObjective, "0", "12")
+	if executers := getExecuters(plan); executers != "idservice1:idagent2,idservice2:idagent3" || plan.TotalCost.String() != "10" || plan.TotalTime.String() != "11" {
+		testLog.Info("Minimum time plan within the budget was", executers, plan.TotalCost, plan.TotalTime)
+		t.FailNow()
+	}
+
+	// MINIMUM REPUTATION FOR ALL THE COMPONENTS AND FOR A SINGLE COMPONENT (THE AGENT WITHOUT REPUTATION HAS ScoreMin)
+	plan = planExecution("plan4", "idservice6", a.CostObjective, "7", "100")
+	if executers := getExecuters(plan); executers != "idservice1:idagent1,idservice2:idagent3" {
+		testLog.Info("Plan with minimum reputation 7 was", executers)
+		t.FailNow()
+	}
+	plan = planExecution("plan5", "idservice6", a.CostObjective, "idservice2:1", "100")
+	if executers := getExecuters(plan); executers != "idservice1:idagent2,idservice2:idagent3" {
+		testLog.Info("Plan with minimum reputation of idservice2 was", executers)
+		t.FailNow()
+	}
+
+	// THE PLANS ARE SAVED
+	res := mockInvoke(mockStub, "1", [][]byte{[]byte(GetExecutionPlan), []byte(a.ExecutionPlanIdPrefix + "idservice6plan3")})
+	var savedPlan a.ExecutionPlan
+	json.Unmarshal(res.Payload, &savedPlan)
+	if res.Status != shim.OK || savedPlan.TxId != "plan3" || getExecuters(savedPlan) != "idservice1:idagent2,idservice2:idagent3" || savedPlan.Budget.String() != "12" {
+		testLog.Info("Saved plan was", string(res.Payload), res.Message)
+		t.FailNow()
+	}
+	res = mockInvoke(mockStub, "1", [][]byte{[]byte(GetExecutionPlansByService), []byte("idservice6")})
+	var plans []a.ExecutionPlan
+	json.Unmarshal(res.Payload, &plans)
+	if res.Status != shim.OK || len(plans) != 5 {
+		testLog.Info("Plans of idservice6 were", string(res.Payload))
+		t.FailNow()
+	}
+
+	// NO FEASIBLE PLAN AND WRONG INPUTS
+	checkBadInvoke(t, mockStub, []string{PlanCompositeExecution, "idservice6", a.CostObjective, "0", "5"})
+	checkBadInvoke(t, mockStub, []string{PlanCompositeExecution, "idservice6", a.CostObjective, "9.5", "100"})
+	checkBadInvoke(t, mockStub, []string{PlanCompositeExecution, "idservice1", a.CostObjective, "0", "100"})
+	checkBadInvoke(t, mockStub, []string{PlanCompositeExecution, "idservice6", "QUALITY", "0", "100"})
+	checkBadInvoke(t, mockStub, []string{PlanCompositeExecution, "idservice6", a.CostObjective, "0", "much"})
+	checkBadInvoke(t, mockStub, []string{GetExecutionPlan, "executionPlanNotExisting"})
+}
+
+func TestAgentGlobalReputation(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Agent Global Reputation", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+	checkInvoke(t, mockStub, []string{SetCredibilityWeighting, "false"})
+
+	getAgentReputation := func(agentId string, agentRole string) a.AgentReputation {
+		res := mockInvoke(mockStub, "1", [][]byte{[]byte(GetAgentGlobalReputation), []byte(agentId), []byte(agentRole)})
+		if res.Status != shim.OK {
+			testLog.Info("GetAgentGlobalReputation failed", string(res.Message))
+			t.FailNow()
+		}
+		var agentReputation a.AgentReputation
+		json.Unmarshal(res.Payload, &agentReputation)
+		return agentReputation
+	}
+
+	// THE REPUTATIONS FROM THE INIT ARE AGGREGATED
+	if agentReputation := getAgentReputation(ExecuterAgentId, a.Executer); agentReputation.Value != "9" || agentReputation.ServiceCount != 1 {
+		testLog.Info("Global reputation of the executer was", agentReputation)
+		t.FailNow()
+	}
+
+	// WEIGHTED BY THE NUMBER OF EVALUATIONS: (8*2 + 5*1) / 3
+	completeServiceExecution(t, mockStub, ExecutedServiceTxId, "idagent1", "idagent2", ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, "idagent1", "idagent1", "idagent2", ExecutedServiceId, ExecutedServiceTxId, ExecutedServiceTimestamp, "10"})
+	completeServiceExecution(t, mockStub, "execServiceTxId2", "idagent1", "idagent2", ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, "idagent1", "idagent1", "idagent2", ExecutedServiceId, "execServiceTxId2", ExecutedServiceTimestamp, "6"})
+	completeServiceExecution(t, mockStub, "execServiceTxId3", "idagent1", "idagent2", ExistingServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, "idagent1", "idagent1", "idagent2", ExistingServiceId, "execServiceTxId3", ExecutedServiceTimestamp, "5"})
+	agentReputation := getAgentReputation("idagent2", a.Executer)
+	if agentReputation.Value != "7" || agentReputation.EvaluationCount != 3 || agentReputation.ServiceCount != 2 || agentReputation.Services[ExecutedServiceId].Value != "8" {
+		testLog.Info("Global reputation of idagent2 was", agentReputation)
+		t.FailNow()
+	}
+
+	// GetAgent INCLUDES THE GLOBAL REPUTATIONS
+	res := mockInvoke(mockStub, "1", [][]byte{[]byte(GetAgent), []byte("idagent2")})
+	var agent a.AgentWithReputation
+	json.Unmarshal(res.Payload, &agent)
+	if res.Status != shim.OK || agent.Name != "agent2" || len(agent.GlobalReputations) != 1 || agent.GlobalReputations[0].Value != "7" {
+		testLog.Info("GetAgent returned", string(res.Payload))
+		t.FailNow()
+	}
+
+	// A NEW SERVICE STARTS FROM THE GLOBAL REPUTATION (WITHOUT EVALUATIONS IT DOESN'T CHANGE THE GLOBAL REPUTATION)
+	checkInvoke(t, mockStub, []string{CreateServiceAgentRelationAndReputation, "idservice2", "idagent2", "3", "4"})
+	checkReputationValue(t, mockStub, "idagent2idservice2"+a.Executer, "7")
+	if agentReputation := getAgentReputation("idagent2", a.Executer); agentReputation.Value != "7" || agentReputation.ServiceCount != 3 {
+		testLog.Info("Global reputation of idagent2 was", agentReputation)
+		t.FailNow()
+	}
+
+	// WITHOUT GLOBAL REPUTATION THE STANDARD VALUE
+	checkInvoke(t, mockStub, []string{CreateServiceAndServiceAgentRelationWithStandardValue, "idservice7", "service7", "service Description 7", "idagent3", "3", "4"})
+	checkReputationValue(t, mockStub, "idagent3idservice7"+a.Executer, a.DefaultInitialReputationValue)
+	if agentReputation := getAgentReputation("idagent3", a.Executer); agentReputation.Value != "6" || agentReputation.EvaluationCount != 0 {
+		testLog.Info("Global reputation of idagent3 was", agentReputation)
+		t.FailNow()
+	}
+
+	// BOTH THE ROLES WITHOUT ROLE
+	res = mockInvoke(mockStub, "1", [][]byte{[]byte(GetAgentGlobalReputation), []byte("idagent2")})
+	var agentReputations []a.AgentReputation
+	json.Unmarshal(res.Payload, &agentReputations)
+	if res.Status != shim.OK || len(agentReputations) != 1 || agentReputations[0].AgentRole != a.Executer {
+		testLog.Info("Global reputations of idagent2 were", string(res.Payload))
+		t.FailNow()
+	}
+	checkBadInvoke(t, mockStub, []string{GetAgentGlobalReputation, "idagent2", a.Demander})
+	checkBadInvoke(t, mockStub, []string{GetAgentGlobalReputation, "idagent2", "OBSERVER"})
+}
+
+func TestColdStartReputation(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Cold Start Reputation", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+	checkInvoke(t, mockStub, []string{SetCredibilityWeighting, "false"})
+	checkInvoke(t, mockStub, []string{CreateCompositeService, "idservice6", "service6", "composite service 6", "idservice1,idservice2"})
+	checkInvoke(t, mockStub, []string{ModifyServiceCategory, "idservice3", "storage"})
+	checkInvoke(t, mockStub, []string{ModifyServiceCategory, "idservice4", "storage"})
+	checkInvoke(t, mockStub, []string{CreateReputation, "idagent1", "idservice1", a.Executer, "8"})
+	checkInvoke(t, mockStub, []string{CreateReputation, "idagent2", "idservice3", a.Executer, "4"})
+	checkInvoke(t, mockStub, []string{CreateReputation, "idagent2", "idservice5", a.Executer, "10"})
+
+	inferInitialReputation := func(agentId string, serviceId string) a.ColdStartInference {
+		res := mockInvoke(mockStub, "1", [][]byte{[]byte(InferInitialReputation), []byte(agentId), []byte(serviceId)})
+		if res.Status != shim.OK {
+			testLog.Info("InferInitialReputation failed", string(res.Message))
+			t.FailNow()
+		}
+		var inference a.ColdStartInference
+		json.Unmarshal(res.Payload, &inference)
+		return inference
+	}
+
+	// SOURCES IN ORDER OF PREFERENCE
+	if inference := inferInitialReputation("idagent1", "idservice2"); inference.Source != a.RelatedServicesColdStart || inference.Value != "8" {
+		testLog.Info("Inference from the same composite parent was", inference)
+		t.FailNow()
+	}
+	if inference := inferInitialReputation("idagent2", "idservice4"); inference.Source != a.RelatedServicesColdStart || inference.Value != "4" || len(inference.BasedOn) != 1 {
+		testLog.Info("Inference from the same category was", inference)
+		t.FailNow()
+	}
+	if inference := inferInitialReputation("idagent2", ExecutedServiceId); inference.Source != a.AgentGlobalColdStart || inference.Value != "7" {
+		testLog.Info("Inference from the global reputation was", inference)
+		t.FailNow()
+	}
+	if inference := inferInitialReputation("idagent3", ExecutedServiceId); inference.Source != a.ServiceMeanColdStart || inference.Value != "9" {
+		testLog.Info("Inference from the service mean was", inference)
+		t.FailNow()
+	}
+	if inference := inferInitialReputation("idagent3", "idservice4"); inference.Source != a.DefaultColdStart || inference.Value != a.DefaultInitialReputationValue {
+		testLog.Info("Default inference was", inference)
+		t.FailNow()
+	}
+
+	// THE NEW RELATION STARTS FROM THE INFERRED VALUE, KEPT AS PRIOR WITH A LOW WEIGHT
+	reputationId := "idagent1idservice2" + a.Executer
+	checkInvoke(t, mockStub, []string{CreateServiceAgentRelationAndReputation, "idservice2", "idagent1", "3", "4"})
+	var reputation a.Reputation
+	json.Unmarshal(mockStub.State[reputationId], &reputation)
+	if reputation.Value.String() != "8" || reputation.ColdStartSource != a.RelatedServicesColdStart || reputation.ColdStartValue != "8" || reputation.ColdStartWeight != "0.5" {
+		testLog.Info("Cold start reputation was", string(mockStub.State[reputationId]))
+		t.FailNow()
+	}
+	// (8*0.5 + 2) / 1.5
+	completeServiceExecution(t, mockStub, ExecutedServiceTxId, "idagent4", "idagent1", "idservice2")
+	checkInvoke(t, mockStub, []string{CreateActivity, "idagent4", "idagent4", "idagent1", "idservice2", ExecutedServiceTxId, ExecutedServiceTimestamp, "2"})
+	checkReputationValue(t, mockStub, reputationId, "4")
+
+	// THE RECOMPUTATION KEEPS THE PRIOR
+	checkInvoke(t, mockStub, []string{RecomputeReputations})
+	json.Unmarshal(mockStub.State[reputationId], &reputation)
+	if reputation.Value.String() != "4" || reputation.EvidenceCount != 1 || reputation.ColdStartSource != a.RelatedServicesColdStart {
+		testLog.Info("Recomputed cold start reputation was", string(mockStub.State[reputationId]))
+		t.FailNow()
+	}
+
+	// WITHOUT WEIGHT THE PRIOR IS IGNORED
+	checkBadInvoke(t, mockStub, []string{SetColdStartWeight, "-1"})
+	checkInvoke(t, mockStub, []string{SetColdStartWeight, "0"})
+	checkInvoke(t, mockStub, []string{CreateServiceAndServiceAgentRelation, "idservice4", "service4", "service Description 4", "idagent2", "3", "4"})
+	json.Unmarshal(mockStub.State["idagent2idservice4"+a.Executer], &reputation)
+	if reputation.Value.String() != "4" || reputation.ColdStartWeight != "0" {
+		testLog.Info("Cold start reputation without weight was", string(mockStub.State["idagent2idservice4"+a.Executer]))
+		t.FailNow()
+	}
+
+	// ONLY AN ADMINISTRATOR CAN CHOOSE THE INITIAL VALUE
+	userMockStub := shim.NewMockStub("Test Cold Start Reputation By Another Organization", simpleChaincode)
+	checkInit(t, userMockStub, getInitArguments())
+	checkBadInvokeAs(t, userMockStub, UserMspId, []string{CreateServiceAndServiceAgentRelation, "idservice2", "service2", "service Description 2", "idagent1", "3", "4", "10"})
+	checkBadInvokeAs(t, userMockStub, UserMspId, []string{ModifyServiceCategory, "idservice2", "storage"})
+	res := mockInvokeAs(userMockStub, UserMspId, "1", lib.ParseStringSliceToByteSlice([]string{CreateServiceAndServiceAgentRelation, "idservice2", "service2", "service Description 2", "idagent1", "3", "4"}))
+	if res.Status != shim.OK {
+		testLog.Info("Relation without initial value refused to a client of another organization", res.Message)
+		t.FailNow()
+	}
+	checkReputationValue(t, userMockStub, "idagent1idservice2"+a.Executer, a.DefaultInitialReputationValue)
+}
+
+// =====================================================================================================================
+// TestNumericValidation - Test the typed values: score range, cost with currency, time as duration, legacy JSON reads
+// =====================================================================================================================
+func TestNumericValidation(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Numeric Validation", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+	checkInvoke(t, mockStub, []string{SetCredibilityWeighting, "false"})
+
+	// SCORES OUT OF THE RANGE [0, 10] ARE REFUSED
+	completeServiceExecution(t, mockStub, ExecutedServiceTxId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+	checkBadInvoke(t, mockStub, []string{CreateActivity, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, ExecutedServiceTxId, ExecutedServiceTimestamp, "11"})
+	checkBadInvoke(t, mockStub, []string{CreateActivity, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, ExecutedServiceTxId, ExecutedServiceTimestamp, "good"})
+	checkBadInvoke(t, mockStub, []string{CreateReputation, "idagent1", "idservice1", a.Executer, "-1"})
+	checkBadInvoke(t, mockStub, []string{ModifyReputationValue, ExecuterAgentId + ExecutedServiceId + a.Executer, "10.5"})
+	checkBadInvoke(t, mockStub, []string{CreateServiceAndServiceAgentRelation, "idservice7", "service7", "service Description 7", "idagent1", "3", "4", "NaN"})
+	checkReputationValue(t, mockStub, ExecuterAgentId+ExecutedServiceId+a.Executer, "9")
+
+	// THE RANGE IS CONFIGURABLE
+	checkInvoke(t, mockStub, []string{SetReputationModelParameters, "0.3", "0", "100"})
+	checkInvoke(t, mockStub, []string{ModifyReputationValue, ExecuterAgentId + ExecutedServiceId + a.Executer, "55"})
+	checkReputationValue(t, mockStub, ExecuterAgentId+ExecutedServiceId+a.Executer, "55")
+
+	// COSTS WITH CURRENCY, TIMES AS DURATIONS
+	checkBadInvoke(t, mockStub, []string{CreateServiceAgentRelation, "idservice1", "idagent1", "-5", "4"})
+	checkBadInvoke(t, mockStub, []string{CreateServiceAgentRelation, "idservice1", "idagent1", "5 chf", "4"})
+	checkBadInvoke(t, mockStub, []string{CreateServiceAgentRelation, "idservice1", "idagent1", "5", "-4"})
+	checkBadInvoke(t, mockStub, []string{CreateServiceAgentRelation, "idservice1", "idagent1", "5", "soon"})
+	checkBadInvoke(t, mockStub, []string{CreateServiceAndServiceAgentRelationWithStandardValue, "idservice7", "service7", "service Description 7", "idagent1", "5", "-1h"})
+	checkBadQuery(t, mockStub, GetServiceNotFoundError, "idservice7")
+	checkInvoke(t, mockStub, []string{CreateServiceAgentRelation, "idservice1", "idagent1", "5.5 CHF", "90m"})
+	checkQuery(t, mockStub, GetServiceRelationAgent, "idservice1idagent1", "{\"RelationId\":\"idservice1idagent1\",\"ServiceId\":\"idservice1\",\"AgentId\":\"idagent1\",\"Cost\":\"5.5 CHF\",\"Time\":\"1.5\"}")
+	checkBadInvoke(t, mockStub, []string{ModifyServiceRelationAgentCost, "idservice1idagent1", "-0.5 CHF"})
+	checkBadInvoke(t, mockStub, []string{ModifyServiceRelationAgentTime, "idservice1idagent1", "2 days"})
+	checkInvoke(t, mockStub, []string{ModifyServiceRelationAgentCost, "idservice1idagent1", "6 EUR"})
+	checkInvoke(t, mockStub, []string{ModifyServiceRelationAgentTime, "idservice1idagent1", "2h30m"})
+	checkQuery(t, mockStub, GetServiceRelationAgent, "idservice1idagent1", "{\"RelationId\":\"idservice1idagent1\",\"ServiceId\":\"idservice1\",\"AgentId\":\"idagent1\",\"Cost\":\"6 EUR\",\"Time\":\"2.5\"}")
+
+	// COSTS IN DIFFERENT CURRENCIES CAN NOT BE COMPARED
+	checkInvoke(t, mockStub, []string{CreateServiceAgentRelation, "idservice1", "idagent2", "5 CHF", "3"})
+	checkBadInvoke(t, mockStub, []string{SelectExecuter, "idservice1", "cost:1"})
+
+	// LEGACY STRING (AND NUMBER) ENCODED VALUES ARE READ
+	mockStub.MockTransactionStart("legacy")
+	mockStub.PutState("idservice2idagent3", []byte("{\"RelationId\":\"idservice2idagent3\",\"ServiceId\":\"idservice2\",\"AgentId\":\"idagent3\",\"Cost\":\"8.50\",\"Time\":6}"))
+	mockStub.PutState("idagent3idservice2EXECUTER", []byte("{\"ReputationId\":\"idagent3idservice2EXECUTER\",\"AgentId\":\"idagent3\",\"ServiceId\":\"idservice2\",\"AgentRole\":\"EXECUTER\",\"Value\":\"7.0\"}"))
+	mockStub.MockTransactionEnd("legacy")
+	checkQuery(t, mockStub, GetServiceRelationAgent, "idservice2idagent3", "{\"RelationId\":\"idservice2idagent3\",\"ServiceId\":\"idservice2\",\"AgentId\":\"idagent3\",\"Cost\":\"8.5\",\"Time\":\"6\"}")
+	checkReputationValue(t, mockStub, "idagent3idservice2EXECUTER", "7")
+}
+
+// =====================================================================================================================
+// TestDecimalArithmetic - Test the fixed-point decimal: rounding half to even, exact results of known inputs, JSON
+// =====================================================================================================================
+func TestDecimalArithmetic(t *testing.T) {
+	parse := func(value string) a.Decimal {
+		decimal, err := a.ParseDecimal(value)
+		if err != nil {
+			testLog.Info("ParseDecimal", value, "failed", err.Error())
+			t.FailNow()
+		}
+		return decimal
+	}
+	checkDecimal := func(name string, decimal a.Decimal, expected string) {
+		if decimal.String() != expected {
+			testLog.Info(name, "was", decimal.String(), "and not", expected)
+			t.FailNow()
+		}
+	}
+
+	// ROUNDING HALF TO EVEN ON THE 9TH DECIMAL PLACE
+	checkDecimal("Parse 1.0000000005", parse("1.0000000005"), "1")
+	checkDecimal("Parse 1.0000000015", parse("1.0000000015"), "1.000000002")
+	checkDecimal("Parse -2.5e-9", parse("-2.5e-9"), "-0.000000002")
+	checkDecimal("Parse -0.0000000035", parse("-0.0000000035"), "-0.000000004")
+	checkDecimal("0.000000005 * 0.5", parse("0.000000005").Mul(parse("0.5")), "0.000000002")
+	checkDecimal("0.000000015 * 0.1", parse("0.000000015").Mul(parse("0.1")), "0.000000002")
+
+	// KNOWN RESULTS (NO FLOATING POINT: 0.1 + 0.2 IS 0.3)
+	checkDecimal("0.1 + 0.2", parse("0.1").Add(parse("0.2")), "0.3")
+	checkDecimal("0.1 * 0.2", parse("0.1").Mul(parse("0.2")), "0.02")
+	checkDecimal("1 / 3", a.NewDecimal(1).Div(a.NewDecimal(3)), "0.333333333")
+	checkDecimal("-2 / 3", a.NewDecimal(-2).Div(a.NewDecimal(3)), "-0.666666667")
+	checkDecimal("7 / 2", a.NewDecimal(7).DivInt(2), "3.5")
+	checkDecimal("55 / 9", a.NewDecimalFromRatio(55, 9), "6.111111111")
+	checkDecimal("sqrt(2)", a.NewDecimal(2).Sqrt(), "1.414213562")
+	checkDecimal("sqrt(6.25)", parse("6.25").Sqrt(), "2.5")
+	checkDecimal("0.5^1", a.NewDecimal(1).HalfPower(), "0.5")
+	checkDecimal("0.5^0.5", parse("0.5").HalfPower(), "0.707106781")
+	checkDecimal("0.5^0.25", parse("0.25").HalfPower(), "0.840896415")
+	checkDecimal("0.5^2.5", parse("2.5").HalfPower(), "0.176776695")
+	checkDecimal("0.5^70", a.NewDecimal(70).HalfPower(), "0")
+	checkDecimal("Float 0.15", a.NewDecimalFromFloat(0.15), "0.15")
+	sum := a.Decimal{}
+	for i := 0; i < 10; i++ {
+		sum = sum.Add(parse("0.1"))
+	}
+	checkDecimal("Sum of ten 0.1", sum, "1")
+	checkDecimal("Saturation", a.NewDecimal(9000000000).MulInt(10), "9223372036.854775807")
+	if _, err := a.NewDecimal(9000000000).AddChecked(a.NewDecimal(9000000000)); err == nil {
+		testLog.Info("Checked addition out of range did not fail")
+		t.FailNow()
+	}
+	if _, err := a.NewDecimal(-9000000000).MulChecked(a.NewDecimal(2)); err == nil {
+		testLog.Info("Checked multiplication out of range did not fail")
+		t.FailNow()
+	}
+	checkedSum, err := parse("0.1").AddChecked(parse("0.2"))
+	if err != nil {
+		testLog.Info("Checked addition failed", err.Error())
+		t.FailNow()
+	}
+	checkDecimal("Checked 0.1 + 0.2", checkedSum, "0.3")
+
+	// COMPARISON
+	if parse("0.3").Cmp(parse("0.1").Add(parse("0.2"))) != 0 || !parse("1.5").LessThan(a.NewDecimal(2)) || !a.NewDecimal(2).GreaterThanOrEqual(parse("2.0")) || parse("-0.1").Sign() != -1 || !(a.Decimal{}).IsZero() {
+		testLog.Info("Wrong comparison")
+		t.FailNow()
+	}
+	checkDecimal("Min", a.MinDecimal(parse("2.5"), parse("-1")), "-1")
+	checkDecimal("Max", a.MaxDecimal(parse("2.5"), parse("-1")), "2.5")
+
+	// NOT NUMBERS AND OUT OF RANGE
+	for _, value := range []string{"", "abc", "NaN", "Inf", "1/3", "1e30"} {
+		if _, err := a.ParseDecimal(value); err == nil {
+			testLog.Info("ParseDecimal", value, "did not fail")
+			t.FailNow()
+		}
+	}
+
+	// JSON: WRITTEN AS DECIMAL STRING, READ FROM A DECIMAL STRING OR A NUMBER
+	decimalAsBytes, _ := json.Marshal(map[string]a.Decimal{"Value": parse("7.250")})
+	if string(decimalAsBytes) != `{"Value":"7.25"}` {
+		testLog.Info("Decimal JSON was", string(decimalAsBytes))
+		t.FailNow()
+	}
+	var decimals map[string]a.Decimal
+	err = json.Unmarshal([]byte(`{"String":"7.25","Number":7.25,"Null":null}`), &decimals)
+	if err != nil || decimals["String"].String() != "7.25" || decimals["Number"].String() != "7.25" || !decimals["Null"].IsZero() {
+		testLog.Info("Decimal JSON read", decimals, err)
+		t.FailNow()
+	}
+	if json.Unmarshal([]byte(`{"Value":"seven"}`), &decimals) == nil {
+		testLog.Info("Decimal JSON read of a wrong value did not fail")
+		t.FailNow()
+	}
+}
+
+// =====================================================================================================================
+// TestDisputeWorkflow - Test the dispute of an activity: open, respond, resolve (upheld or overturned) and the effect on
+// the reputation of the evaluated agent
+// =====================================================================================================================
+func TestDisputeWorkflow(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Dispute Workflow", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+	checkInvoke(t, mockStub, []string{SetCredibilityWeighting, "false"})
+	reputationId := ExecuterAgentId + ExecutedServiceId + a.Executer
+	firstEvaluationId := DemanderAgentId + DemanderAgentId + ExecuterAgentId + "tx1"
+	secondEvaluationId := DemanderAgentId + DemanderAgentId + ExecuterAgentId + "tx2"
+	completeServiceExecution(t, mockStub, "tx1", DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "tx1", ExecutedServiceTimestamp, "3"})
+	completeServiceExecution(t, mockStub, "tx2", DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "tx2", ExecutedServiceTimestamp, "5"})
+	checkReputationValue(t, mockStub, reputationId, "4")
+
+	getDispute := func(evaluationId string) a.Dispute {
+		res := mockInvoke(mockStub, "1", [][]byte{[]byte(QueryDispute), []byte(a.DisputeIdPrefix + evaluationId)})
+		if res.Status != shim.OK {
+			testLog.Info("QueryDispute failed", string(res.Message))
+			t.FailNow()
+		}
+		var dispute a.Dispute
+		json.Unmarshal(res.Payload, &dispute)
+		return dispute
+	}
+	evidenceHash := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	// ONLY THE EVALUATED AGENT OPENS A DISPUTE, WITH VALID EVIDENCE HASHES
+	checkBadInvoke(t, mockStub, []string{OpenDispute, firstEvaluationId, DemanderAgentId, "unfair rating"})
+	checkBadInvoke(t, mockStub, []string{OpenDispute, firstEvaluationId, ExecuterAgentId, "unfair rating", "notahash"})
+	checkBadInvoke(t, mockStub, []string{OpenDispute, "idevaluation0", ExecuterAgentId, "unfair rating"})
+	checkBadInvoke(t, mockStub, []string{RespondDispute, firstEvaluationId, DemanderAgentId, "fair rating"})
+
+	// WHILE OPEN THE DISPUTED ACTIVITY IS EXCLUDED
+	// ONLY THE OWNER OF THE EVALUATED AGENT CAN OPEN THE DISPUTE
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{OpenDispute, firstEvaluationId, ExecuterAgentId, "unfair rating"})
+	checkInvoke(t, mockStub, []string{OpenDispute, firstEvaluationId, ExecuterAgentId, "unfair rating", evidenceHash})
+	checkBadInvoke(t, mockStub, []string{OpenDispute, firstEvaluationId, ExecuterAgentId, "unfair rating"})
+	if dispute := getDispute(firstEvaluationId); dispute.Status != a.DisputeOpen || dispute.RespondentAgentId != DemanderAgentId || len(dispute.EvidenceHashes) != 1 {
+		testLog.Info("Opened dispute was", dispute)
+		t.FailNow()
+	}
+	checkReputationValue(t, mockStub, reputationId, "5")
+
+	// ONLY THE WRITER RESPONDS, ONCE
+	checkBadInvoke(t, mockStub, []string{RespondDispute, firstEvaluationId, ExecuterAgentId, "fair rating"})
+	// ONLY THE OWNER OF THE WRITER AGENT CAN RESPOND TO THE DISPUTE
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{RespondDispute, firstEvaluationId, DemanderAgentId, "fair rating"})
+	checkInvoke(t, mockStub, []string{RespondDispute, firstEvaluationId, DemanderAgentId, "fair rating"})
+	checkBadInvoke(t, mockStub, []string{RespondDispute, firstEvaluationId, DemanderAgentId, "fair rating"})
+	if dispute := getDispute(firstEvaluationId); dispute.Status != a.DisputeResponded || dispute.Response != "fair rating" {
+		testLog.Info("Responded dispute was", dispute)
+		t.FailNow()
+	}
+
+	// UPHELD: THE ACTIVITY COUNTS AGAIN
+	checkBadInvoke(t, mockStub, []string{ResolveDispute, firstEvaluationId, a.DisputeOpen})
+	checkInvoke(t, mockStub, []string{ResolveDispute, firstEvaluationId, a.DisputeUpheld, "the rating stands"})
+	checkBadInvoke(t, mockStub, []string{ResolveDispute, firstEvaluationId, a.DisputeOverturned})
+	checkReputationValue(t, mockStub, reputationId, "4")
+
+	// OVERTURNED: THE ACTIVITY IS VOID, NOT DELETED
+	checkInvoke(t, mockStub, []string{OpenDispute, secondEvaluationId, ExecuterAgentId, "never rated"})
+	checkReputationValue(t, mockStub, reputationId, "3")
+	checkInvoke(t, mockStub, []string{ResolveDispute, secondEvaluationId, a.DisputeOverturned})
+	checkReputationValue(t, mockStub, reputationId, "3")
+	var activity a.Activity
+	json.Unmarshal(mockStub.State[secondEvaluationId], &activity)
+	if !activity.Void || activity.Value.String() != "5" {
+		testLog.Info("Overturned activity was", string(mockStub.State[secondEvaluationId]))
+		t.FailNow()
+	}
+	checkInvoke(t, mockStub, []string{RecomputeReputations})
+	checkReputationValue(t, mockStub, reputationId, "3")
+	checkBadInvoke(t, mockStub, []string{OpenDispute, secondEvaluationId, ExecuterAgentId, "never rated"})
+	res := mockInvoke(mockStub, "1", [][]byte{[]byte(GetDisputesByAgent), []byte(DemanderAgentId)})
+	var disputes []a.Dispute
+	json.Unmarshal(res.Payload, &disputes)
+	if res.Status != shim.OK || len(disputes) != 2 {
+		testLog.Info("Disputes of the writer were", string(res.Payload))
+		t.FailNow()
+	}
+
+	// DOWN-WEIGHTED INSTEAD OF EXCLUDED
+	checkBadInvoke(t, mockStub, []string{SetDisputedEvaluationWeight, "2"})
+	checkInvoke(t, mockStub, []string{SetDisputedEvaluationWeight, "0.5"})
+	completeServiceExecution(t, mockStub, "tx3", DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "tx3", ExecutedServiceTimestamp, "6"})
+	checkInvoke(t, mockStub, []string{OpenDispute, DemanderAgentId + DemanderAgentId + ExecuterAgentId + "tx3", ExecuterAgentId, "unfair rating"})
+	// (3 + 6*0.5) / 1.5
+	checkReputationValue(t, mockStub, reputationId, "4")
+}
+
+// =====================================================================================================================
+// TestDisputeFromCommittedState - Test that the reputation refreshed by OpenDispute and ResolveDispute does not depend
+// on reading the dispute and the activity written in the same transaction (as on a peer)
+// =====================================================================================================================
+func TestDisputeFromCommittedState(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Dispute From Committed State", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+	checkInvoke(t, mockStub, []string{SetCredibilityWeighting, "false"})
+	reputationId := ExecuterAgentId + ExecutedServiceId + a.Executer
+	firstEvaluationId := DemanderAgentId + DemanderAgentId + ExecuterAgentId + "tx1"
+	secondEvaluationId := DemanderAgentId + DemanderAgentId + ExecuterAgentId + "tx2"
+	completeServiceExecution(t, mockStub, "tx1", DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "tx1", ExecutedServiceTimestamp, "3"})
+	completeServiceExecution(t, mockStub, "tx2", DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "tx2", ExecutedServiceTimestamp, "5"})
+	checkReputationValue(t, mockStub, reputationId, "4")
+
+	// SAME REPUTATIONS AS IN TestDisputeWorkflow
+	steps := []struct {
+		functionAndArgs []string
+		value           string
+	}{
+		{[]string{OpenDispute, firstEvaluationId, ExecuterAgentId, "unfair rating"}, "5"},
+		{[]string{ResolveDispute, firstEvaluationId, a.DisputeUpheld, "the rating stands"}, "4"},
+		{[]string{OpenDispute, secondEvaluationId, ExecuterAgentId, "never rated"}, "3"},
+		{[]string{ResolveDispute, secondEvaluationId, a.DisputeOverturned}, "3"},
+	}
+	for i, step := range steps {
+		res := mockInvokeCommittedReads(mockStub, "disputeTx"+strconv.Itoa(i), lib.ParseStringSliceToByteSlice(step.functionAndArgs))
+		if res.Status != shim.OK {
+			testLog.Info("Invoke", step.functionAndArgs, "on the committed state failed", res.Message)
+			t.FailNow()
+		}
+		checkReputationValue(t, mockStub, reputationId, step.value)
+	}
+
+	// THE REPLAYED EVIDENCE DOES NOT COUNT THE VOID ACTIVITY
+	var reputation a.Reputation
+	json.Unmarshal(mockStub.State[reputationId], &reputation)
+	if reputation.EvidenceCount != 1 {
+		testLog.Info("Reputation after the overturn was", string(mockStub.State[reputationId]))
+		t.FailNow()
+	}
+}
+
+// =====================================================================================================================
+// TestCommitRevealEvaluations - Test the two-phase evaluation of an executed service: commit the hashes, reveal once
+// both sides committed or after the commit deadline, unrevealed commitments are missing reviews
+// =====================================================================================================================
+func TestCommitRevealEvaluations(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Commit Reveal Evaluations", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+	checkInvoke(t, mockStub, []string{SetCredibilityWeighting, "false"})
+	demanderHash := a.ComputeCommitmentHash("3", "demander salt")
+	executerHash := a.ComputeCommitmentHash("8", "executer salt")
+	commitmentId := func(writerAgentId string, executedServiceTxId string) string {
+		return a.CommitmentIdPrefix + writerAgentId + DemanderAgentId + ExecuterAgentId + executedServiceTxId
+	}
+	setDeadlines := func(commitmentId string, commitDeadline string, revealDeadline string) {
+		var commitment a.EvaluationCommitment
+		json.Unmarshal(mockStub.State[commitmentId], &commitment)
+		commitment.CommitDeadline = commitDeadline
+		commitment.RevealDeadline = revealDeadline
+		mockStub.State[commitmentId], _ = json.Marshal(commitment)
+	}
+
+	for _, executedServiceTxId := range []string{"tx1", "tx2", "tx3", "tx4"} {
+		completeServiceExecution(t, mockStub, executedServiceTxId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+	}
+
+	// COMMIT
+	checkBadInvoke(t, mockStub, []string{CommitEvaluation, "idagent1", DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "tx1", demanderHash})
+	checkBadInvoke(t, mockStub, []string{CommitEvaluation, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "tx1", "3"})
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{CommitEvaluation, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "tx1", demanderHash})
+	checkInvoke(t, mockStub, []string{CommitEvaluation, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "tx1", demanderHash})
+	checkBadInvoke(t, mockStub, []string{CommitEvaluation, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "tx1", demanderHash})
+	checkBadInvoke(t, mockStub, []string{CommitEvaluation, ExecuterAgentId, DemanderAgentId, ExecuterAgentId, "idservice1", "tx1", executerHash})
+
+	// NO REVEAL (AND NO PLAIN ACTIVITY) BEFORE THE COUNTERPART COMMITTED
+	checkBadInvoke(t, mockStub, []string{RevealEvaluation, DemanderAgentId, DemanderAgentId, ExecuterAgentId, "tx1", ExecutedServiceTimestamp, "3", "demander salt"})
+	completeServiceExecution(t, mockStub, "tx1", DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+	checkBadInvoke(t, mockStub, []string{CreateActivity, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "tx1", ExecutedServiceTimestamp, "3"})
+
+	// BOTH COMMITTED: REVEAL WITH THE COMMITTED VALUE AND SALT
+	checkInvoke(t, mockStub, []string{CommitEvaluation, ExecuterAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "tx1", executerHash})
+	checkBadInvoke(t, mockStub, []string{RevealEvaluation, DemanderAgentId, DemanderAgentId, ExecuterAgentId, "tx1", ExecutedServiceTimestamp, "4", "demander salt"})
+	checkBadInvoke(t, mockStub, []string{RevealEvaluation, DemanderAgentId, DemanderAgentId, ExecuterAgentId, "tx1", ExecutedServiceTimestamp, "3", "executer salt"})
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{RevealEvaluation, DemanderAgentId, DemanderAgentId, ExecuterAgentId, "tx1", ExecutedServiceTimestamp, "3", "demander salt"})
+	checkInvoke(t, mockStub, []string{RevealEvaluation, DemanderAgentId, DemanderAgentId, ExecuterAgentId, "tx1", ExecutedServiceTimestamp, "3", "demander salt"})
+	checkBadInvoke(t, mockStub, []string{RevealEvaluation, DemanderAgentId, DemanderAgentId, ExecuterAgentId, "tx1", ExecutedServiceTimestamp, "3", "demander salt"})
+	checkInvoke(t, mockStub, []string{RevealEvaluation, ExecuterAgentId, DemanderAgentId, ExecuterAgentId, "tx1", ExecutedServiceTimestamp, "8", "executer salt"})
+	checkReputationValue(t, mockStub, ExecuterAgentId+ExecutedServiceId+a.Executer, "3")
+	checkReputationValue(t, mockStub, DemanderAgentId+ExecutedServiceId+a.Demander, "8")
+	var activity a.Activity
+	json.Unmarshal(mockStub.State[DemanderAgentId+DemanderAgentId+ExecuterAgentId+"tx1"], &activity)
+	if activity.Value.String() != "3" || activity.ExecutedServiceId != ExecutedServiceId {
+		testLog.Info("Revealed activity was", string(mockStub.State[DemanderAgentId+DemanderAgentId+ExecuterAgentId+"tx1"]))
+		t.FailNow()
+	}
+
+	// AFTER THE COMMIT DEADLINE ONE SIDE REVEALS ALONE, THE COMMIT PHASE IS CLOSED
+	checkInvoke(t, mockStub, []string{CommitEvaluation, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "tx2", demanderHash})
+	setDeadlines(commitmentId(DemanderAgentId, "tx2"), "2000-01-01T00:00:00Z", "2100-01-01T00:00:00Z")
+	checkBadInvoke(t, mockStub, []string{CommitEvaluation, ExecuterAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "tx2", executerHash})
+	checkBadInvoke(t, mockStub, []string{CloseEvaluationCommitments, "tx2"})
+	checkInvoke(t, mockStub, []string{RevealEvaluation, DemanderAgentId, DemanderAgentId, ExecuterAgentId, "tx2", ExecutedServiceTimestamp, "3", "demander salt"})
+
+	// NOT REVEALED BEFORE THE REVEAL DEADLINE: MISSING REVIEW
+	checkInvoke(t, mockStub, []string{CommitEvaluation, ExecuterAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "tx3", executerHash})
+	setDeadlines(commitmentId(ExecuterAgentId, "tx3"), "2000-01-01T00:00:00Z", "2000-01-02T00:00:00Z")
+	checkBadInvoke(t, mockStub, []string{RevealEvaluation, ExecuterAgentId, DemanderAgentId, ExecuterAgentId, "tx3", ExecutedServiceTimestamp, "8", "executer salt"})
+	res := mockInvoke(mockStub, "1", [][]byte{[]byte(GetMissingReviewsByAgent), []byte(ExecuterAgentId)})
+	var missingReviews []a.EvaluationCommitment
+	json.Unmarshal(res.Payload, &missingReviews)
+	if res.Status != shim.OK || len(missingReviews) != 1 || missingReviews[0].ExecutedServiceTxid != "tx3" {
+		testLog.Info("Missing reviews were", string(res.Payload))
+		t.FailNow()
+	}
+	checkInvoke(t, mockStub, []string{CloseEvaluationCommitments, "tx3"})
+	var commitment a.EvaluationCommitment
+	json.Unmarshal(mockStub.State[commitmentId(ExecuterAgentId, "tx3")], &commitment)
+	if commitment.Status != a.CommitmentMissing {
+		testLog.Info("Closed commitment was", string(mockStub.State[commitmentId(ExecuterAgentId, "tx3")]))
+		t.FailNow()
+	}
+	checkReputationValue(t, mockStub, DemanderAgentId+ExecutedServiceId+a.Demander, "8")
+
+	// NO COMMITMENT AFTER THE EVALUATION OF THE COUNTERPART WRITTEN WITHOUT COMMITMENT
+	completeServiceExecution(t, mockStub, "tx5", DemanderAgentId, "idagent1", ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, "idagent1", DemanderAgentId, "idagent1", ExecutedServiceId, "tx5", ExecutedServiceTimestamp, "4"})
+	checkBadInvoke(t, mockStub, []string{CommitEvaluation, DemanderAgentId, DemanderAgentId, "idagent1", ExecutedServiceId, "tx5", demanderHash})
+
+	// THE MISSING REVIEW HALVES THE CREDIBILITY OF THE EXECUTER (1 OF 2 COMMITMENTS): (0.3 * 0.5 * 8 + 0.5 * 4) / (0.15 + 0.5)
+	checkInvoke(t, mockStub, []string{SetCredibilityWeighting, "true", "0.5"})
+	demanderReputationId := DemanderAgentId + ExecutedServiceId + a.Demander
+	expectedResp := "{\"ReputationId\":\"" + demanderReputationId + "\",\"AgentId\":\"" + DemanderAgentId + "\",\"ServiceId\":\"" + ExecutedServiceId + "\",\"AgentRole\":\"" + a.Demander + "\",\"Value\":\"4.923076923\"}"
+	checkQueryArgs(t, mockStub, [][]byte{[]byte(ComputeReputationWithModel), []byte(DemanderAgentId), []byte(ExecutedServiceId), []byte(a.Demander), []byte(a.MeanModelName)}, expectedResp)
+	checkInvoke(t, mockStub, []string{SetCredibilityWeighting, "false"})
+
+	// WINDOWS OF THE CONFIGURATION
+	checkBadInvoke(t, mockStub, []string{SetCommitRevealWindows, "0s", "1h"})
+	checkBadInvoke(t, mockStub, []string{SetCommitRevealWindows, "1h", "tomorrow"})
+	checkInvoke(t, mockStub, []string{SetCommitRevealWindows, "2h", "1h"})
+	checkInvoke(t, mockStub, []string{CommitEvaluation, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "tx4", demanderHash})
+	json.Unmarshal(mockStub.State[commitmentId(DemanderAgentId, "tx4")], &commitment)
+	committed, _ := time.Parse(a.TxTimestampLayout, commitment.CommittedTimestamp)
+	revealDeadline, _ := time.Parse(a.TxTimestampLayout, commitment.RevealDeadline)
+	if revealDeadline.Sub(committed) != 3*time.Hour {
+		testLog.Info("Commitment with windows of 2h and 1h was", string(mockStub.State[commitmentId(DemanderAgentId, "tx4")]))
+		t.FailNow()
+	}
+}
+
+// =====================================================================================================================
+// TestServiceExecutionLifecycle - Test the lifecycle of a service execution and the activities allowed only for a
+// completed execution, once per side
+// =====================================================================================================================
+func TestServiceExecutionLifecycle(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Service Execution Lifecycle", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+	checkInvoke(t, mockStub, []string{SetCredibilityWeighting, "false"})
+	checkInvoke(t, mockStub, []string{Mint, DemanderAgentId, "10"})
+	requestServiceExecution := func(executionId string, demanderAgentId string, executerAgentId string) int32 {
+		res := mockInvoke(mockStub, executionId, [][]byte{[]byte(RequestServiceExecution), []byte(demanderAgentId), []byte(executerAgentId), []byte(ExecutedServiceId)})
+		return res.Status
+	}
+	getExecution := func(executionId string) a.ServiceExecution {
+		var execution a.ServiceExecution
+		json.Unmarshal(mockStub.State[executionId], &execution)
+		return execution
+	}
+
+	// REQUEST: THE TRANSACTION ID IS THE EXECUTION ID, COST AND TIME OF THE RELATION, ONLY BY THE OWNER OF THE DEMANDER
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{RequestServiceExecution, DemanderAgentId, ExecuterAgentId, ExecutedServiceId})
+	if requestServiceExecution("execution1", DemanderAgentId, "idagent1") == shim.OK || requestServiceExecution("execution1", ExecuterAgentId, ExecuterAgentId) == shim.OK {
+		testLog.Info("Request of a service without relation or to itself accepted")
+		t.FailNow()
+	}
+	if requestServiceExecution("execution1", DemanderAgentId, ExecuterAgentId) != shim.OK || requestServiceExecution("execution1", DemanderAgentId, ExecuterAgentId) == shim.OK {
+		testLog.Info("Request of the service execution failed or repeated")
+		t.FailNow()
+	}
+	checkInvoke(t, mockStub, []string{ModifyServiceRelationAgentCost, ExecutedServiceId + ExecuterAgentId, "6"})
+	if execution := getExecution("execution1"); execution.Status != a.ExecutionRequested || execution.Cost.String() != "5" || execution.Time.String() != "7" || execution.RelationId != ExecutedServiceId+ExecuterAgentId {
+		testLog.Info("Requested service execution was", string(mockStub.State["execution1"]))
+		t.FailNow()
+	}
+
+	// NO EVALUATION BEFORE THE COMPLETION
+	checkBadInvoke(t, mockStub, []string{CreateActivity, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execution1", ExecutedServiceTimestamp, "8"})
+
+	// ACCEPTED AND DELIVERED BY THE EXECUTER, COMPLETED BY THE DEMANDER (ONLY BY THE OWNERS OF THE AGENTS)
+	checkBadInvoke(t, mockStub, []string{AcceptServiceExecution, "execution1", DemanderAgentId})
+	checkBadInvoke(t, mockStub, []string{DeliverServiceExecution, "execution1", ExecuterAgentId})
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{AcceptServiceExecution, "execution1", ExecuterAgentId})
+	checkInvoke(t, mockStub, []string{AcceptServiceExecution, "execution1", ExecuterAgentId})
+	checkBadInvoke(t, mockStub, []string{CompleteServiceExecution, "execution1", DemanderAgentId})
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{DeliverServiceExecution, "execution1", ExecuterAgentId})
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{DeliverServiceExecution, "execution1", ExecuterAgentId, "1"})
+	checkInvoke(t, mockStub, []string{DeliverServiceExecution, "execution1", ExecuterAgentId})
+	checkBadInvoke(t, mockStub, []string{CancelServiceExecution, "execution1", DemanderAgentId})
+	checkBadInvoke(t, mockStub, []string{CompleteServiceExecution, "execution1", ExecuterAgentId})
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{CompleteServiceExecution, "execution1", DemanderAgentId})
+	checkInvoke(t, mockStub, []string{CompleteServiceExecution, "execution1", DemanderAgentId})
+	if execution := getExecution("execution1"); execution.Status != a.ExecutionCompleted || execution.AcceptedTimestamp == "" || execution.DeliveredTimestamp == "" || execution.CompletedTimestamp == "" {
+		testLog.Info("Completed service execution was", string(mockStub.State["execution1"]))
+		t.FailNow()
+	}
+
+	// EVALUATED ONCE PER SIDE, ONLY BY THE AGENTS OF THE EXECUTION (AND THEIR OWNERS)
+	checkBadInvoke(t, mockStub, []string{CreateActivity, "idagent1", "idagent1", ExecuterAgentId, ExecutedServiceId, "execution1", ExecutedServiceTimestamp, "8"})
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{CreateActivity, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execution1", ExecutedServiceTimestamp, "8"})
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{CreateActivity, ExecuterAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execution1", ExecutedServiceTimestamp, "6"})
+	checkBadInvoke(t, mockStub, []string{CreateActivity, DemanderAgentId, DemanderAgentId, ExecuterAgentId, "idservice1", "execution1", ExecutedServiceTimestamp, "8"})
+	checkInvoke(t, mockStub, []string{CreateActivity, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execution1", ExecutedServiceTimestamp, "8"})
+	checkBadInvoke(t, mockStub, []string{CreateActivity, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execution1", ExecutedServiceTimestamp, "8"})
+	checkInvoke(t, mockStub, []string{CreateActivity, ExecuterAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execution1", ExecutedServiceTimestamp, "6"})
+	checkReputationValue(t, mockStub, ExecuterAgentId+ExecutedServiceId+a.Executer, "8")
+	checkBadInvoke(t, mockStub, []string{CreateActivity, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execution0", ExecutedServiceTimestamp, "8"})
+
+	// CANCELLED BEFORE THE DELIVERY, BY ONE OF THE AGENTS
+	if requestServiceExecution("execution2", DemanderAgentId, ExecuterAgentId) != shim.OK {
+		testLog.Info("Request of the second service execution failed")
+		t.FailNow()
+	}
+	checkBadInvoke(t, mockStub, []string{CancelServiceExecution, "execution2", "idagent1"})
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{CancelServiceExecution, "execution2", DemanderAgentId})
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{CancelServiceExecution, "execution2", ExecuterAgentId})
+	checkInvoke(t, mockStub, []string{CancelServiceExecution, "execution2", ExecuterAgentId})
+	checkBadInvoke(t, mockStub, []string{AcceptServiceExecution, "execution2", ExecuterAgentId})
+	if execution := getExecution("execution2"); execution.Status != a.ExecutionCancelled || execution.CancelledBy != ExecuterAgentId || execution.Cost.String() != "6" {
+		testLog.Info("Cancelled service execution was", string(mockStub.State["execution2"]))
+		t.FailNow()
+	}
+	checkBadInvoke(t, mockStub, []string{CreateActivity, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execution2", ExecutedServiceTimestamp, "8"})
+
+	res := mockInvoke(mockStub, "1", [][]byte{[]byte(GetServiceExecutionsByAgent), []byte(DemanderAgentId)})
+	var executions []a.ServiceExecution
+	json.Unmarshal(res.Payload, &executions)
+	if res.Status != shim.OK || len(executions) != 2 {
+		testLog.Info("Service executions of the demander were", string(res.Payload))
+		t.FailNow()
+	}
+}
+
+// =====================================================================================================================
+// TestServiceRequestMarketplace - Test the service requests, the bids and the acceptance of a bid
+// =====================================================================================================================
+func TestServiceRequestMarketplace(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Service Request Marketplace", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+	checkInvoke(t, mockStub, []string{SetCredibilityWeighting, "false"})
+	checkInvoke(t, mockStub, []string{CreateServiceAgentRelation, ExecutedServiceId, "idagent1", "10", "5"})
+	checkInvoke(t, mockStub, []string{ModifyOrCreateReputationValue, ExecuterAgentId, ExecutedServiceId, a.Executer, "6"})
+	checkInvoke(t, mockStub, []string{Mint, DemanderAgentId, "10"})
+	deadline := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	createServiceRequest := func(requestId string, serviceId string, maxCost string, deadline string, minReputation string) int32 {
+		res := mockInvoke(mockStub, requestId, [][]byte{[]byte(CreateServiceRequest), []byte(DemanderAgentId), []byte(serviceId), []byte(maxCost), []byte(deadline), []byte(minReputation)})
+		return res.Status
+	}
+	getOpenServiceRequests := func() []a.ServiceRequest {
+		res := mockInvoke(mockStub, "1", [][]byte{[]byte(GetOpenServiceRequestsByService), []byte(ExecutedServiceId)})
+		var requests []a.ServiceRequest
+		json.Unmarshal(res.Payload, &requests)
+		return requests
+	}
+
+	// REQUEST: EXISTING SERVICE, DEADLINE IN THE FUTURE, MIN REPUTATION IN THE SCORE RANGE
+	if createServiceRequest("request0", "idservice0", "8", deadline, "5") == shim.OK ||
+		createServiceRequest("request0", ExecutedServiceId, "8", "2018-07-23T16:51:01Z", "5") == shim.OK ||
+		createServiceRequest("request0", ExecutedServiceId, "8", deadline, "11") == shim.OK {
+		testLog.Info("Wrong service request accepted")
+		t.FailNow()
+	}
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{CreateServiceRequest, DemanderAgentId, ExecutedServiceId, "8", deadline, "5"})
+	if createServiceRequest("request1", ExecutedServiceId, "8 EUR", deadline, "5") != shim.OK || createServiceRequest("request2", ExecutedServiceId, "8", deadline, "0") != shim.OK {
+		testLog.Info("Creation of the service requests failed")
+		t.FailNow()
+	}
+	if requests := getOpenServiceRequests(); len(requests) != 2 || requests[0].DemanderAgentId != DemanderAgentId || requests[0].Status != a.RequestOpen {
+		testLog.Info("Open service requests were", requests)
+		t.FailNow()
+	}
+
+	// BIDS: ONLY AGENTS OF THE SERVICE, WITHIN THE MAX COST AND THE MIN REPUTATION, ONCE
+	checkBadInvoke(t, mockStub, []string{SubmitBid, "request1", DemanderAgentId, "5", "7"})
+	checkBadInvoke(t, mockStub, []string{SubmitBid, "request1", "idagent2", "5", "7"})
+	checkBadInvoke(t, mockStub, []string{SubmitBid, "request1", "idagent1", "5", "7"})
+	checkBadInvoke(t, mockStub, []string{SubmitBid, "request1", ExecuterAgentId, "9", "7"})
+	checkBadInvoke(t, mockStub, []string{SubmitBid, "request1", ExecuterAgentId, "5 USD", "7"})
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{SubmitBid, "request1", ExecuterAgentId, "7 EUR", "7"})
+	checkInvoke(t, mockStub, []string{SubmitBid, "request1", ExecuterAgentId, "7 EUR", "7"})
+	checkBadInvoke(t, mockStub, []string{SubmitBid, "request1", ExecuterAgentId, "6", "7"})
+	checkInvoke(t, mockStub, []string{SubmitBid, "request2", "idagent1", "8", "5"})
+	checkInvoke(t, mockStub, []string{SubmitBid, "request2", ExecuterAgentId, "6", "4"})
+	res := mockInvoke(mockStub, "1", [][]byte{[]byte(GetBidsByRequest), []byte("request2")})
+	var bids []a.Bid
+	json.Unmarshal(res.Payload, &bids)
+	if res.Status != shim.OK || len(bids) != 2 || bids[0].Status != a.BidSubmitted || bids[0].RelationId != ExecutedServiceId+bids[0].ExecuterAgentId {
+		testLog.Info("Bids of the service request were", string(res.Payload))
+		t.FailNow()
+	}
+
+	// ACCEPTANCE: ONLY BY THE (OWNER OF THE) DEMANDER, THE EXECUTION IS CREATED WITH THE COST AND TIME OF THE BID
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{AcceptBid, "request2" + ExecuterAgentId, DemanderAgentId})
+	checkBadInvoke(t, mockStub, []string{AcceptBid, "request2" + ExecuterAgentId, ExecuterAgentId})
+	checkBadInvoke(t, mockStub, []string{AcceptBid, "request2idagent2", DemanderAgentId})
+	res = mockInvoke(mockStub, "acceptance1", [][]byte{[]byte(AcceptBid), []byte("request2" + ExecuterAgentId), []byte(DemanderAgentId)})
+	if res.Status != shim.OK {
+		testLog.Info("Acceptance of the bid failed", res.Message)
+		t.FailNow()
+	}
+	var request a.ServiceRequest
+	json.Unmarshal(mockStub.State["request2"], &request)
+	var execution a.ServiceExecution
+	json.Unmarshal(mockStub.State["acceptance1"], &execution)
+	var rejectedBid a.Bid
+	json.Unmarshal(mockStub.State["request2idagent1"], &rejectedBid)
+	if request.Status != a.RequestAssigned || request.AcceptedBidId != "request2"+ExecuterAgentId || request.ExecutionId != "acceptance1" ||
+		execution.Status != a.ExecutionAccepted || execution.ExecuterAgentId != ExecuterAgentId || execution.Cost.String() != "6" || execution.Time.String() != "4" ||
+		rejectedBid.Status != a.BidRejected {
+		testLog.Info("Assigned service request was", string(mockStub.State["request2"]), "with execution", string(mockStub.State["acceptance1"]))
+		t.FailNow()
+	}
+	checkBadInvoke(t, mockStub, []string{AcceptBid, "request2idagent1", DemanderAgentId})
+	checkBadInvoke(t, mockStub, []string{SubmitBid, "request2", "idagent3", "5", "7"})
+	checkBadInvoke(t, mockStub, []string{CancelServiceRequest, "request2", DemanderAgentId})
+	checkInvoke(t, mockStub, []string{DeliverServiceExecution, "acceptance1", ExecuterAgentId})
+	checkInvoke(t, mockStub, []string{CompleteServiceExecution, "acceptance1", DemanderAgentId})
+	checkInvoke(t, mockStub, []string{CreateActivity, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "acceptance1", ExecutedServiceTimestamp, "8"})
+
+	// CANCELLATION: ONLY BY THE DEMANDER, NO MORE BIDS
+	checkBadInvoke(t, mockStub, []string{CancelServiceRequest, "request1", ExecuterAgentId})
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{CancelServiceRequest, "request1", DemanderAgentId})
+	checkInvoke(t, mockStub, []string{CancelServiceRequest, "request1", DemanderAgentId})
+	checkBadInvoke(t, mockStub, []string{AcceptBid, "request1" + ExecuterAgentId, DemanderAgentId})
+	if requests := getOpenServiceRequests(); len(requests) != 0 {
+		testLog.Info("Open service requests were", requests)
+		t.FailNow()
+	}
+}
+
+// =====================================================================================================================
+// TestTokenEscrow - Test the token balances and the escrow of the service executions
+// =====================================================================================================================
+func TestTokenEscrow(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Token Escrow", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+	checkInvoke(t, mockStub, []string{SetCredibilityWeighting, "false"})
+	checkBalance := func(agentId string, expectedBalance string) {
+		res := mockInvoke(mockStub, "1", [][]byte{[]byte(GetBalance), []byte(agentId)})
+		var accounts []a.Account
+		json.Unmarshal(res.Payload, &accounts)
+		for _, account := range accounts {
+			if account.Balance.Currency == "" && account.Balance.String() == expectedBalance {
+				return
+			}
+		}
+		testLog.Info("Balance of", agentId, "was", string(res.Payload), "expected", expectedBalance)
+		t.FailNow()
+	}
+	getEscrowStatus := func(executionId string) string {
+		res := mockInvoke(mockStub, "1", [][]byte{[]byte(QueryEscrow), []byte(executionId)})
+		var escrow a.Escrow
+		json.Unmarshal(res.Payload, &escrow)
+		return escrow.Status
+	}
+	checkInvokeTx := func(txId string, functionAndArgs []string) {
+		res := mockInvoke(mockStub, txId, lib.ParseStringSliceToByteSlice(functionAndArgs))
+		if res.Status != shim.OK {
+			testLog.Info("Invoke", functionAndArgs, "failed", string(res.Message))
+			t.FailNow()
+		}
+	}
+	acceptServiceExecution := func(executionId string) {
+		res := mockInvoke(mockStub, executionId, [][]byte{[]byte(RequestServiceExecution), []byte(DemanderAgentId), []byte(ExecuterAgentId), []byte(ExecutedServiceId)})
+		if res.Status != shim.OK {
+			testLog.Info("Request of the service execution failed", res.Message)
+			t.FailNow()
+		}
+		checkInvokeTx("accept"+executionId, []string{AcceptServiceExecution, executionId, ExecuterAgentId})
+	}
+
+	// MINT AND TRANSFER: POSITIVE AMOUNTS, EXISTING AGENTS, ENOUGH BALANCE
+	checkBadInvoke(t, mockStub, []string{Mint, "idagent0", "20"})
+	checkBadInvoke(t, mockStub, []string{Mint, DemanderAgentId, "0"})
+	checkBadInvoke(t, mockStub, []string{Mint, DemanderAgentId, "-20"})
+	checkInvokeTx("mint1", []string{Mint, DemanderAgentId, "20"})
+	checkInvokeTx("mint2", []string{Mint, DemanderAgentId, "5 EUR"})
+	checkBadInvoke(t, mockStub, []string{Transfer, DemanderAgentId, DemanderAgentId, "3"})
+	checkBadInvoke(t, mockStub, []string{Transfer, DemanderAgentId, "idagent0", "3"})
+	checkBadInvoke(t, mockStub, []string{Transfer, DemanderAgentId, "idagent1", "6 EUR"})
+	checkBadInvoke(t, mockStub, []string{Transfer, "idagent1", DemanderAgentId, "3"})
+	checkInvokeTx("transfer1", []string{Transfer, DemanderAgentId, "idagent1", "3"})
+	checkBalance(DemanderAgentId, "17")
+	checkBalance("idagent1", "3")
+
+	// NO BALANCE OVER THE BIGGEST AMOUNT: THE MINT IS REFUSED INSTEAD OF SATURATING
+	checkInvokeTx("mintmax", []string{Mint, "idagent2", "9223372036"})
+	checkBadInvoke(t, mockStub, []string{Mint, "idagent2", "1"})
+	// (the failed transfer is not committed, the debit of the demander is discarded)
+	res := mockInvokeCommittedReads(mockStub, "transfermax", lib.ParseStringSliceToByteSlice([]string{Transfer, DemanderAgentId, "idagent2", "1"}))
+	if res.Status == shim.OK {
+		testLog.Info("Transfer over the biggest balance did not fail")
+		t.FailNow()
+	}
+	checkBalance("idagent2", "9223372036")
+	checkBalance(DemanderAgentId, "17")
+
+	// ONLY THE OWNER OF THE FROM AGENT (THE CLIENT THAT CREATED IT) TRANSFERS ITS TOKENS
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{Transfer, DemanderAgentId, "idagent1", "3"})
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{Transfer, DemanderAgentId, "idagent0", "3"})
+	for _, functionAndArgs := range [][]string{{CreateAgent, "idagent7", "agent7", "address7"}, {Mint, "idagent7", "2"}, {Transfer, "idagent7", "idagent5", "2"}} {
+		// (the administrators organization mints)
+		creatorMspId := UserMspId
+		if functionAndArgs[0] == Mint {
+			creatorMspId = AdminMspId
+		}
+		res := mockInvokeAs(mockStub, creatorMspId, functionAndArgs[0]+"7", lib.ParseStringSliceToByteSlice(functionAndArgs))
+		if res.Status != shim.OK {
+			testLog.Info("Invoke", functionAndArgs, "by", creatorMspId, "failed", res.Message)
+			t.FailNow()
+		}
+	}
+	checkBalance("idagent5", "2")
+	checkBadInvokeAs(t, mockStub, UserMspId, []string{Transfer, "idagent5", "idagent7", "1"})
+	// an agent without owner (created before the owners) is moved by nobody
+	var agent a.Agent
+	json.Unmarshal(mockStub.State["idagent5"], &agent)
+	agent.Owner = ""
+	mockStub.State["idagent5"], _ = json.Marshal(agent)
+	checkBadInvoke(t, mockStub, []string{Transfer, "idagent5", "idagent7", "1"})
+	checkBalance("idagent5", "2")
+
+	// ESCROW AT THE ACCEPTANCE, PAID AT THE COMPLETION
+	acceptServiceExecution("execution1")
+	checkBalance(DemanderAgentId, "12")
+	if getEscrowStatus("execution1") != a.EscrowHeld {
+		testLog.Info("Escrow of the accepted execution not held")
+		t.FailNow()
+	}
+	checkInvoke(t, mockStub, []string{DeliverServiceExecution, "execution1", ExecuterAgentId})
+	checkInvokeTx("complete1", []string{CompleteServiceExecution, "execution1", DemanderAgentId})
+	checkBalance(ExecuterAgentId, "5")
+	if getEscrowStatus("execution1") != a.EscrowReleased {
+		testLog.Info("Escrow of the completed execution not released")
+		t.FailNow()
+	}
+
+	// REFUND AT THE CANCELLATION, NO ACCEPTANCE WITHOUT BALANCE
+	acceptServiceExecution("execution2")
+	checkBalance(DemanderAgentId, "7")
+	checkInvokeTx("cancel2", []string{CancelServiceExecution, "execution2", DemanderAgentId})
+	checkBalance(DemanderAgentId, "12")
+	if getEscrowStatus("execution2") != a.EscrowRefunded {
+		testLog.Info("Escrow of the cancelled execution not refunded")
+		t.FailNow()
+	}
+	checkInvokeTx("transfer2", []string{Transfer, DemanderAgentId, "idagent1", "10"})
+	res = mockInvoke(mockStub, "execution3", [][]byte{[]byte(RequestServiceExecution), []byte(DemanderAgentId), []byte(ExecuterAgentId), []byte(ExecutedServiceId)})
+	if res.Status != shim.OK {
+		testLog.Info("Request of the service execution failed", res.Message)
+		t.FailNow()
+	}
+	checkBadInvoke(t, mockStub, []string{AcceptServiceExecution, "execution3", ExecuterAgentId})
+	checkInvokeTx("transfer3", []string{Transfer, "idagent1", DemanderAgentId, "13"})
+
+	// REFUND OF THE PAID EXECUTION WHEN THE DISPUTE ON THE EVALUATION OF THE DEMANDER IS UPHELD
+	evaluationId := DemanderAgentId + DemanderAgentId + ExecuterAgentId + "execution1"
+	checkInvoke(t, mockStub, []string{CreateActivity, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execution1", ExecutedServiceTimestamp, "2"})
+	checkInvoke(t, mockStub, []string{OpenDispute, evaluationId, ExecuterAgentId, "unfair rating"})
+	checkInvokeTx("resolve1", []string{ResolveDispute, evaluationId, a.DisputeUpheld, "the service was not delivered"})
+	checkBalance(DemanderAgentId, "20")
+	checkBalance(ExecuterAgentId, "0")
+	if getEscrowStatus("execution1") != a.EscrowRefunded {
+		testLog.Info("Escrow of the disputed execution not refunded")
+		t.FailNow()
+	}
+
+	// EVERY MOVEMENT IS A LEDGER ENTRY
+	res = mockInvoke(mockStub, "1", [][]byte{[]byte(GetLedgerEntriesByAgent), []byte(ExecuterAgentId)})
+	var entries []a.LedgerEntry
+	json.Unmarshal(res.Payload, &entries)
+	if len(entries) != 2 || entries[0].Type == entries[1].Type {
+		testLog.Info("Ledger entries of the executer were", string(res.Payload))
+		t.FailNow()
+	}
+	res = mockInvoke(mockStub, "1", [][]byte{[]byte(GetLedgerEntriesByAgent), []byte(DemanderAgentId)})
+	json.Unmarshal(res.Payload, &entries)
+	if len(entries) != 9 {
+		testLog.Info("Ledger entries of the demander were", string(res.Payload))
+		t.FailNow()
+	}
+
+	// THE EXECUTER SPENT THE PAYMENT: THE UPHELD DISPUTE REFUNDS ITS BALANCE AND RECORDS THE REST AS A DEBT
+	acceptServiceExecution("execution4")
+	checkInvoke(t, mockStub, []string{DeliverServiceExecution, "execution4", ExecuterAgentId})
+	checkInvokeTx("complete4", []string{CompleteServiceExecution, "execution4", DemanderAgentId})
+	checkInvokeTx("transfer4", []string{Transfer, ExecuterAgentId, "idagent1", "3"})
+	checkBalance(DemanderAgentId, "15")
+	checkBalance(ExecuterAgentId, "2")
+	evaluationId = DemanderAgentId + DemanderAgentId + ExecuterAgentId + "execution4"
+	checkInvoke(t, mockStub, []string{CreateActivity, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execution4", ExecutedServiceTimestamp, "2"})
+	checkInvoke(t, mockStub, []string{OpenDispute, evaluationId, ExecuterAgentId, "unfair rating"})
+	checkInvokeTx("resolve4", []string{ResolveDispute, evaluationId, a.DisputeUpheld, "the service was not delivered"})
+	checkBalance(DemanderAgentId, "17")
+	checkBalance(ExecuterAgentId, "0")
+	res = mockInvoke(mockStub, "1", [][]byte{[]byte(QueryEscrow), []byte("execution4")})
+	var escrow a.Escrow
+	json.Unmarshal(res.Payload, &escrow)
+	if escrow.Status != a.EscrowRefunded || escrow.UnrecoveredAmount == nil || escrow.UnrecoveredAmount.String() != "3" {
+		testLog.Info("Escrow of the spent execution was", string(res.Payload))
+		t.FailNow()
+	}
+	var debtEntry a.LedgerEntry
+	json.Unmarshal(mockStub.State[a.LedgerEntryIdPrefix+"resolve4"+a.EntryDebt], &debtEntry)
+	if debtEntry.FromAgentId != ExecuterAgentId || debtEntry.ToAgentId != DemanderAgentId || debtEntry.Amount.String() != "3" {
+		testLog.Info("Debt entry of the executer was", debtEntry)
+		t.FailNow()
+	}
+}
+
+// =====================================================================================================================
+// TestServiceLevelAgreement - Test the SLA records of the completed executions, the report and the penalties
+// =====================================================================================================================
+func TestServiceLevelAgreement(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Service Level Agreement", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+	checkInvoke(t, mockStub, []string{SetCredibilityWeighting, "false"})
+	checkInvoke(t, mockStub, []string{Mint, DemanderAgentId, "30"})
+	reputationId := ExecuterAgentId + ExecutedServiceId + a.Executer
+	checkInvokeTx := func(txId string, functionAndArgs []string) {
+		res := mockInvoke(mockStub, txId, lib.ParseStringSliceToByteSlice(functionAndArgs))
+		if res.Status != shim.OK {
+			testLog.Info("Invoke", functionAndArgs, "failed", string(res.Message))
+			t.FailNow()
+		}
+	}
+	// promised: cost 5, time 7 (relation idservice99idagent99), actual time = deliveredTimestamp - acceptedTimestamp
+	executeService := func(executionId string, actualCost string, acceptedTimestamp string, deliveredTimestamp string) {
+		checkInvokeTx(executionId, []string{RequestServiceExecution, DemanderAgentId, ExecuterAgentId, ExecutedServiceId})
+		checkInvokeTx("accept"+executionId, []string{AcceptServiceExecution, executionId, ExecuterAgentId})
+		if actualCost == "" {
+			checkInvokeTx("deliver"+executionId, []string{DeliverServiceExecution, executionId, ExecuterAgentId})
+		} else {
+			checkInvokeTx("deliver"+executionId, []string{DeliverServiceExecution, executionId, ExecuterAgentId, actualCost})
+		}
+		var execution a.ServiceExecution
+		json.Unmarshal(mockStub.State[executionId], &execution)
+		execution.AcceptedTimestamp = acceptedTimestamp
+		execution.DeliveredTimestamp = deliveredTimestamp
+		mockStub.State[executionId], _ = json.Marshal(execution)
+		checkInvokeTx("complete"+executionId, []string{CompleteServiceExecution, executionId, DemanderAgentId})
+	}
+	getSlaReport := func() a.SlaReport {
+		res := mockInvoke(mockStub, "1", [][]byte{[]byte(GetSlaReport), []byte(ExecuterAgentId), []byte(ExecutedServiceId)})
+		var report a.SlaReport
+		json.Unmarshal(res.Payload, &report)
+		return report
+	}
+
+	// ON TIME AND AT THE AGREED COST: COMPLIANT
+	executeService("execution1", "", "2018-07-23T06:00:00Z", "2018-07-23T12:00:00Z")
+	checkInvoke(t, mockStub, []string{CreateActivity, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execution1", ExecutedServiceTimestamp, "9"})
+	checkReputationValue(t, mockStub, reputationId, "9")
+	if report := getSlaReport(); report.Executions != 1 || report.Breaches != 0 || report.ComplianceRate.String() != "1" {
+		testLog.Info("SLA report was", report)
+		t.FailNow()
+	}
+
+	// LATE (10 OVER 7): PENALTY 7 ON THE REPUTATION OF THE EXECUTER
+	executeService("execution2", "", "2018-07-23T06:00:00Z", "2018-07-23T16:00:00Z")
+	checkReputationValue(t, mockStub, reputationId, "8")
+	var record a.SlaRecord
+	json.Unmarshal(mockStub.State[a.SlaRecordIdPrefix+"execution2"], &record)
+	if record.Compliant || record.ActualTime.String() != "10" || record.ComplianceRatio.String() != "0.7" {
+		testLog.Info("SLA record of the late execution was", string(mockStub.State[a.SlaRecordIdPrefix+"execution2"]))
+		t.FailNow()
+	}
+
+	// UNDER THE AGREED COST: COMPLIANT, THE REST OF THE ESCROW GOES BACK TO THE DEMANDER
+	checkBadInvoke(t, mockStub, []string{DeliverServiceExecution, "execution3", ExecuterAgentId, "-1"})
+	executeService("execution3", "2.5", "2018-07-23T06:00:00Z", "2018-07-23T12:00:00Z")
+	checkReputationValue(t, mockStub, reputationId, "8")
+
+	// OVER THE AGREED COST (10 OVER 5): PENALTY 5, ONLY THE ESCROW IS PAID
+	executeService("execution4", "10", "2018-07-23T06:00:00Z", "2018-07-23T12:00:00Z")
+	checkReputationValue(t, mockStub, reputationId, "7")
+	res := mockInvoke(mockStub, "1", [][]byte{[]byte(GetBalance), []byte(ExecuterAgentId)})
+	var accounts []a.Account
+	json.Unmarshal(res.Payload, &accounts)
+	if len(accounts) != 1 || accounts[0].Balance.String() != "17.5" {
+		testLog.Info("Balance of the executer was", string(res.Payload))
+		t.FailNow()
+	}
+
+	// REPORT: 2 BREACHES OVER 4 EXECUTIONS, TIME DEVIATION (-1 + 3 - 1 - 1) / 7 / 4, COST DEVIATION (0 + 0 - 0.5 + 1) / 4
+	report := getSlaReport()
+	if report.Executions != 4 || report.Breaches != 2 || report.ComplianceRate.String() != "0.5" || report.AverageTimeDeviation.String() != "0" || report.AverageCostDeviation.String() != "0.125" {
+		testLog.Info("SLA report was", report)
+		t.FailNow()
+	}
+
+	// NO PENALTY WITH WEIGHT 0
+	checkBadInvoke(t, mockStub, []string{SetSlaPenaltyWeight, "2"})
+	checkInvoke(t, mockStub, []string{SetSlaPenaltyWeight, "0"})
+	executeService("execution5", "10", "2018-07-23T06:00:00Z", "2018-07-23T16:00:00Z")
+	checkReputationValue(t, mockStub, reputationId, "7")
+}
+
+// =====================================================================================================================
+// TestServiceLevelFromCommittedState - the penalty of the SLA breach is computed in the transaction completing the
+// execution, the SLA record just written is not returned by the range query on the committed state
+// =====================================================================================================================
+func TestServiceLevelFromCommittedState(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Service Level From Committed State", simpleChaincode)
+	referenceMockStub := shim.NewMockStub("Test Service Level From Committed State Reference", simpleChaincode)
+
+	reputationId := ExecuterAgentId + ExecutedServiceId + a.Executer
+	checkInvokeTx := func(stub *shim.MockStub, txId string, functionAndArgs []string, committedReads bool) {
+		var res pb.Response
+		if committedReads {
+			res = mockInvokeCommittedReads(stub, txId, lib.ParseStringSliceToByteSlice(functionAndArgs))
+		} else {
+			res = mockInvoke(stub, txId, lib.ParseStringSliceToByteSlice(functionAndArgs))
+		}
+		if res.Status != shim.OK {
+			testLog.Info("Invoke", functionAndArgs, "failed", string(res.Message))
+			t.FailNow()
+		}
+	}
+	// promised: cost 5, time 7 (relation idservice99idagent99), the completion reads only the committed state
+	executeService := func(stub *shim.MockStub, executionId string, actualCost string, deliveredTimestamp string, committedReads bool) {
+		checkInvokeTx(stub, executionId, []string{RequestServiceExecution, DemanderAgentId, ExecuterAgentId, ExecutedServiceId}, false)
+		checkInvokeTx(stub, "accept"+executionId, []string{AcceptServiceExecution, executionId, ExecuterAgentId}, false)
+		checkInvokeTx(stub, "deliver"+executionId, []string{DeliverServiceExecution, executionId, ExecuterAgentId, actualCost}, false)
+		var execution a.ServiceExecution
+		json.Unmarshal(stub.State[executionId], &execution)
+		execution.AcceptedTimestamp = "2018-07-23T06:00:00Z"
+		execution.DeliveredTimestamp = deliveredTimestamp
+		stub.State[executionId], _ = json.Marshal(execution)
+		checkInvokeTx(stub, "complete"+executionId, []string{CompleteServiceExecution, executionId, DemanderAgentId}, committedReads)
+	}
+
+	for _, stub := range []*shim.MockStub{mockStub, referenceMockStub} {
+		checkInit(t, stub, getInitArguments())
+		checkInvoke(t, stub, []string{SetCredibilityWeighting, "false"})
+		checkInvoke(t, stub, []string{Mint, DemanderAgentId, "30"})
+		executeService(stub, "execution1", "5", "2018-07-23T12:00:00Z", false)
+		checkInvoke(t, stub, []string{CreateActivity, DemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execution1", ExecutedServiceTimestamp, "9"})
+	}
+
+	// LATE (10 OVER 7), THEN LATE AND OVER THE AGREED COST (10 OVER 5): SAME PENALTIES AS WITH THE WRITES OF THE
+	// TRANSACTION VISIBLE
+	expectedValues := []string{"8", "7"}
+	for i, executionId := range []string{"execution2", "execution10"} {
+		executeService(mockStub, executionId, []string{"5", "10"}[i], "2018-07-23T16:00:00Z", true)
+		executeService(referenceMockStub, executionId, []string{"5", "10"}[i], "2018-07-23T16:00:00Z", false)
+		var reputation, referenceReputation a.Reputation
+		json.Unmarshal(mockStub.State[reputationId], &reputation)
+		json.Unmarshal(referenceMockStub.State[reputationId], &referenceReputation)
+		if reputation.Value.String() != expectedValues[i] || reputation.Value.String() != referenceReputation.Value.String() || reputation.EvidenceCount != referenceReputation.EvidenceCount {
+			testLog.Info("Reputation from the committed state was", string(mockStub.State[reputationId]), "and not", string(referenceMockStub.State[reputationId]))
+			t.FailNow()
+		}
+	}
+}
+
+// =====================================================================================================================
+// TestReputationFromCommittedState - Test that the reputation update of CreateActivity does not depend on reading the
+// activity written in the same transaction (a peer returns only the state committed before the transaction)
+// =====================================================================================================================
+func TestReputationFromCommittedState(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Reputation From Committed State", simpleChaincode)
+	referenceMockStub := shim.NewMockStub("Test Reputation From Committed State Reference", simpleChaincode)
+
+	reputationId := ExecuterAgentId + ExecutedServiceId + a.Executer
+	for _, stub := range []*shim.MockStub{mockStub, referenceMockStub} {
+		checkInit(t, stub, getInitArguments())
+		checkInvoke(t, stub, []string{SetCredibilityWeighting, "false"})
+		completeServiceExecution(t, stub, ExecutedServiceTxId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+		completeServiceExecution(t, stub, "execServiceTxId2", DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+	}
+
+	// THE FIRST AND THE SECOND EVALUATION: SAME REPUTATION AS WITH THE WRITES OF THE TRANSACTION VISIBLE
+	evaluations := [][]string{
+		{CreateActivity, WritingDemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, ExecutedServiceTxId, ExecutedServiceTimestamp, "10"},
+		{CreateActivity, WritingDemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execServiceTxId2", ExecutedServiceTimestamp, "4"},
+	}
+	expectedValues := []string{"10", "7"}
+	for i, evaluation := range evaluations {
+		txId := []string{"tx1", "tx2"}[i]
+		res := mockInvokeCommittedReads(mockStub, txId, lib.ParseStringSliceToByteSlice(evaluation))
+		if res.Status != shim.OK {
+			testLog.Info("CreateActivity on the committed state failed", res.Message)
+			t.FailNow()
+		}
+		res = mockInvoke(referenceMockStub, txId, lib.ParseStringSliceToByteSlice(evaluation))
+		if res.Status != shim.OK {
+			testLog.Info("CreateActivity failed", res.Message)
+			t.FailNow()
+		}
+		var reputation, referenceReputation a.Reputation
+		json.Unmarshal(mockStub.State[reputationId], &reputation)
+		json.Unmarshal(referenceMockStub.State[reputationId], &referenceReputation)
+		if reputation.Value.String() != expectedValues[i] || reputation.Value != referenceReputation.Value || reputation.EvidenceCount != referenceReputation.EvidenceCount {
+			testLog.Info("Reputation from the committed state was", string(mockStub.State[reputationId]), "and not", string(referenceMockStub.State[reputationId]))
+			t.FailNow()
+		}
+	}
+}
+
+// =====================================================================================================================
+// TestEvaluationOrder - Test that the evaluations are in order of writing on the ledger (TxTimestamp) and not of the
+// ExecutedServiceTimestamp chosen by the client, which orders only the activities written without TxTimestamp
+// =====================================================================================================================
+func TestEvaluationOrder(t *testing.T) {
+	simpleChaincode := new(SimpleChaincode)
+	simpleChaincode.testMode = true
+	mockStub := shim.NewMockStub("Test Evaluation Order", simpleChaincode)
+
+	// Init
+	checkInit(t, mockStub, getInitArguments())
+	checkInvoke(t, mockStub, []string{SetCredibilityWeighting, "false"})
+	checkInvoke(t, mockStub, []string{SetColdStartWeight, "0"})
+	checkInvoke(t, mockStub, []string{SetReputationModel, a.EwmaModelName, ExecutedServiceId})
+
+	// THE LAST EVALUATION WRITTEN IS THE LAST ONE FOR THE EWMA, WHATEVER ITS EXECUTED SERVICE TIMESTAMP
+	completeServiceExecution(t, mockStub, ExecutedServiceTxId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, WritingDemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, ExecutedServiceTxId, "2030-01-01T00:00:00Z", "10"})
+	completeServiceExecution(t, mockStub, "execServiceTxId2", DemanderAgentId, ExecuterAgentId, ExecutedServiceId)
+	checkInvoke(t, mockStub, []string{CreateActivity, WritingDemanderAgentId, DemanderAgentId, ExecuterAgentId, ExecutedServiceId, "execServiceTxId2", "2000-01-01T00:00:00Z", "0"})
+	// 0.3 * 0 + 0.7 * 10
+	checkReputationValue(t, mockStub, ExecuterAgentId+ExecutedServiceId+a.Executer, "7")
+
+	// THE ACTIVITIES WITHOUT TXTIMESTAMP COME FIRST, IN ORDER OF EXECUTED SERVICE TIMESTAMP
+	activities := []a.Activity{
+		{EvaluationId: "evaluation1", TxTimestamp: "2020-01-02T00:00:00Z", ExecutedServiceTimestamp: "2000-01-01T00:00:00Z"},
+		{EvaluationId: "evaluation2", ExecutedServiceTimestamp: "b"},
+		{EvaluationId: "evaluation3", TxTimestamp: "2020-01-01T00:00:00Z", ExecutedServiceTimestamp: "2030-01-01T00:00:00Z"},
+		{EvaluationId: "evaluation4", ExecutedServiceTimestamp: "a"},
+		{EvaluationId: "evaluation0", TxTimestamp: "2020-01-01T00:00:00Z"},
+	}
+	evaluations, _ := a.GetEvaluationSliceFromActivities(activities)
+	var evaluationIds []string
+	for _, evaluation := range evaluations {
+		evaluationIds = append(evaluationIds, evaluation.Activity.EvaluationId)
+	}
+	if order := strings.Join(evaluationIds, ","); order != "evaluation4,evaluation2,evaluation0,evaluation3,evaluation1" {
+		testLog.Info("Evaluations in order", order)
+		t.FailNow()
+	}
+}
+
 /*
 func TestTradeWorkflow_LetterOfCredit(t *testing.T) {
 	scc := new(TradeWorkflowChaincode)